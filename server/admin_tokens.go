@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/pkg/errors"
+)
+
+// ListConnectedUsers returns the users with a stored token, most recently connected first.
+// Tokens are left out.
+func (s *adminService) ListConnectedUsers() ([]command.ConnectedUser, error) {
+	keys, err := kvstore.ListKeysWithPrefix(&s.p.client.KV, userTokenKeyPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tokens")
+	}
+
+	users := []command.ConnectedUser{}
+	for _, key := range keys {
+		var userToken UserToken
+		if err := s.p.client.KV.Get(key, &userToken); err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s", key)
+		}
+		if userToken.UserID == "" {
+			continue
+		}
+		users = append(users, command.ConnectedUser{
+			UserID: userToken.UserID,
+			UserConnection: command.UserConnection{
+				ConnectedAt: userToken.ConnectedAt,
+				ExpiresAt:   userToken.ExpiresAt,
+				Refreshable: userToken.RefreshToken != "",
+			},
+		})
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ConnectedAt.After(users[j].ConnectedAt)
+	})
+	return users, nil
+}
+
+// RevokeUserToken force-disconnects a user and revokes their token with Yandex. The revocation is
+// audited as an action of the admin and the user is told in a DM.
+func (s *adminService) RevokeUserToken(adminID, userID string) (*command.TokenRevocation, error) {
+	revocation, err := s.p.removeUserToken(userID)
+	if err != nil {
+		s.p.recordAudit(audit.EventTokenRevoke, adminID, "", false, map[string]string{"target_user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	if revocation == nil {
+		return nil, &command.NotConnectedError{}
+	}
+
+	details := map[string]string{"target_user_id": userID, "token_revocation": revocation.Status}
+	if revocation.Error != "" {
+		details["error"] = revocation.Error
+	}
+	s.p.recordAudit(audit.EventTokenRevoke, adminID, "", true, details)
+	s.p.emitEvent(eventUserDisconnected, map[string]interface{}{"user_id": userID})
+	s.p.sendConnectionStatus(userID, connectionStatusDisconnected)
+	return revocation, nil
+}
+
+// RevokeAllUserTokens force-disconnects every connected user, e.g. after the OAuth application's
+// credentials were rotated, and returns the revocations by user. Users that couldn't be
+// disconnected are left out and reported in the error.
+func (s *adminService) RevokeAllUserTokens(adminID string) (map[string]*command.TokenRevocation, error) {
+	keys, err := kvstore.ListKeysWithPrefix(&s.p.client.KV, userTokenKeyPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tokens")
+	}
+
+	revocations := map[string]*command.TokenRevocation{}
+	failed := []string{}
+	for _, key := range keys {
+		userID := strings.TrimPrefix(key, userTokenKeyPrefix)
+		revocation, err := s.RevokeUserToken(adminID, userID)
+		var notConnected *command.NotConnectedError
+		switch {
+		case errors.As(err, &notConnected):
+			continue
+		case err != nil:
+			s.p.API.LogError("Failed to revoke token", "user_id", userID, "error", err.Error())
+			failed = append(failed, userID)
+			continue
+		}
+		revocations[userID] = revocation
+	}
+
+	if len(failed) > 0 {
+		return revocations, errors.Errorf("failed to disconnect %d users: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return revocations, nil
+}
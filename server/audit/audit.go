@@ -0,0 +1,314 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	keyPrefix    = "telemost_audit_"
+	seqKeyPrefix = "telemost_audit_seq_"
+	daysKey      = "telemost_audit_days"
+)
+
+// EventType identifies what happened in an audit event
+type EventType string
+
+const (
+	EventConnect       EventType = "connect"
+	EventDisconnect    EventType = "disconnect"
+	EventTokenRefresh  EventType = "token_refresh"
+	EventMeetingCreate EventType = "meeting_create"
+	EventMeetingEnd    EventType = "meeting_end"
+	EventConfigChange  EventType = "config_change"
+	EventRateLimited   EventType = "rate_limited"
+	EventLiveStream    EventType = "live_stream"
+	EventWebhook       EventType = "webhook_meeting_create"
+	EventUserExport    EventType = "user_data_export"
+	EventUserPurge     EventType = "user_data_purge"
+	EventTokenRevoke   EventType = "token_revoke"
+)
+
+// Event is a single structured audit record
+type Event struct {
+	ID        string            `json:"id"`
+	Type      EventType         `json:"type"`
+	UserID    string            `json:"user_id,omitempty"`
+	ChannelID string            `json:"channel_id,omitempty"`
+	Success   bool              `json:"success"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Filter narrows down the events returned by Query
+type Filter struct {
+	UserID string
+	Since  time.Time
+	Limit  int
+}
+
+// Store persists audit events in the plugin KV store, grouped by the UTC day they were created
+// on. Each day has a counter and its events are keyed by sequence number, and an index lists the
+// days that have events, so queries and retention only load the days they cover.
+type Store struct {
+	client *pluginapi.Client
+}
+
+// NewStore creates a new audit store
+func NewStore(client *pluginapi.Client) *Store {
+	return &Store{
+		client: client,
+	}
+}
+
+// Record stores an event and mirrors it to the server log
+func (s *Store) Record(event *Event) error {
+	event.ID = model.NewId()
+	event.CreatedAt = time.Now().UTC()
+
+	s.client.Log.Info("Telemost audit event",
+		"type", string(event.Type),
+		"user_id", event.UserID,
+		"channel_id", event.ChannelID,
+		"success", event.Success,
+		"details", event.Details,
+	)
+
+	day := dayOf(event.CreatedAt)
+	seq, err := s.nextSeq(day)
+	if err != nil {
+		return fmt.Errorf("failed to store audit event: %w", err)
+	}
+	if seq == 1 {
+		if err := s.addDay(day); err != nil {
+			return fmt.Errorf("failed to index audit day: %w", err)
+		}
+	}
+
+	if _, err := s.client.KV.Set(eventKey(day, seq), event); err != nil {
+		return fmt.Errorf("failed to store audit event: %w", err)
+	}
+	return nil
+}
+
+// Query returns the events matching the filter, newest first
+func (s *Store) Query(filter Filter) ([]*Event, error) {
+	days, err := s.days()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*Event{}
+	since := dayOf(filter.Since)
+	for i := len(days) - 1; i >= 0 && days[i] >= since; i-- {
+		count, err := s.dayCount(days[i])
+		if err != nil {
+			return nil, err
+		}
+
+		for seq := count; seq >= 1; seq-- {
+			var event Event
+			if err := s.client.KV.Get(eventKey(days[i], seq), &event); err != nil {
+				return nil, fmt.Errorf("failed to load audit event: %w", err)
+			}
+			if event.ID == "" || event.CreatedAt.Before(filter.Since) || (filter.UserID != "" && event.UserID != filter.UserID) {
+				continue
+			}
+
+			events = append(events, &event)
+			if filter.Limit > 0 && len(events) >= filter.Limit {
+				return events, nil
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// Export writes the events matching the filter as JSON lines, oldest first
+func (s *Store) Export(w io.Writer, filter Filter) error {
+	events, err := s.Query(filter)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := encoder.Encode(events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBefore removes the events of the days before the given time and returns how many were
+// deleted. Events are expired a whole day at a time.
+func (s *Store) DeleteBefore(before time.Time) (int, error) {
+	days, err := s.days()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	cutoff := dayOf(before)
+	for _, day := range days {
+		if day >= cutoff {
+			break
+		}
+
+		count, err := s.dayCount(day)
+		if err != nil {
+			return deleted, err
+		}
+		for seq := 1; seq <= count; seq++ {
+			if err := s.client.KV.Delete(eventKey(day, seq)); err != nil {
+				return deleted, fmt.Errorf("failed to delete audit event: %w", err)
+			}
+		}
+		if err := s.client.KV.Delete(seqKey(day)); err != nil {
+			return deleted, fmt.Errorf("failed to delete audit day: %w", err)
+		}
+		if err := s.removeDay(day); err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// DeleteUser removes the events of a user and returns how many were deleted
+func (s *Store) DeleteUser(userID string) (int, error) {
+	days, err := s.days()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, day := range days {
+		count, err := s.dayCount(day)
+		if err != nil {
+			return deleted, err
+		}
+
+		for seq := 1; seq <= count; seq++ {
+			var event Event
+			if err := s.client.KV.Get(eventKey(day, seq), &event); err != nil {
+				return deleted, fmt.Errorf("failed to load audit event: %w", err)
+			}
+			if event.ID == "" || event.UserID != userID {
+				continue
+			}
+			if err := s.client.KV.Delete(eventKey(day, seq)); err != nil {
+				return deleted, fmt.Errorf("failed to delete audit event: %w", err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Count returns the number of events recorded on the days that are kept. Events deleted by
+// DeleteUser are still counted.
+func (s *Store) Count() (int, error) {
+	days, err := s.days()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, day := range days {
+		count, err := s.dayCount(day)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// nextSeq atomically increments the event counter of a day and returns the new value
+func (s *Store) nextSeq(day string) (int, error) {
+	var seq int
+	err := s.client.KV.SetAtomicWithRetries(seqKey(day), func(oldValue []byte) (interface{}, error) {
+		seq = 0
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, &seq); err != nil {
+				return nil, err
+			}
+		}
+		seq++
+		return seq, nil
+	})
+	return seq, err
+}
+
+// dayCount returns how many events were recorded on a day
+func (s *Store) dayCount(day string) (int, error) {
+	var count int
+	if err := s.client.KV.Get(seqKey(day), &count); err != nil {
+		return 0, fmt.Errorf("failed to load audit day: %w", err)
+	}
+	return count, nil
+}
+
+// days returns the days that have events, oldest first
+func (s *Store) days() ([]string, error) {
+	days := []string{}
+	if err := s.client.KV.Get(daysKey, &days); err != nil {
+		return nil, fmt.Errorf("failed to load audit days: %w", err)
+	}
+	return days, nil
+}
+
+// addDay adds a day to the index of days that have events
+func (s *Store) addDay(day string) error {
+	return s.updateDays(func(days []string) []string {
+		i := sort.SearchStrings(days, day)
+		if i < len(days) && days[i] == day {
+			return days
+		}
+		return append(days[:i], append([]string{day}, days[i:]...)...)
+	})
+}
+
+// removeDay removes a day from the index of days that have events
+func (s *Store) removeDay(day string) error {
+	return s.updateDays(func(days []string) []string {
+		i := sort.SearchStrings(days, day)
+		if i < len(days) && days[i] == day {
+			return append(days[:i], days[i+1:]...)
+		}
+		return days
+	})
+}
+
+func (s *Store) updateDays(update func(days []string) []string) error {
+	return s.client.KV.SetAtomicWithRetries(daysKey, func(oldValue []byte) (interface{}, error) {
+		days := []string{}
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, &days); err != nil {
+				return nil, err
+			}
+		}
+		return update(days), nil
+	})
+}
+
+// dayOf returns the UTC day of a time as YYYYMMDD, which sorts like the time
+func dayOf(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+func eventKey(day string, seq int) string {
+	return fmt.Sprintf("%s%s_%d", keyPrefix, day, seq)
+}
+
+func seqKey(day string) string {
+	return seqKeyPrefix + day
+}
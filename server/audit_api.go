@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// recordAudit stores an audit event, logging instead of failing the caller when that isn't possible
+func (p *Plugin) recordAudit(eventType audit.EventType, userID, channelID string, success bool, details map[string]string) {
+	if p.audit == nil {
+		return
+	}
+
+	event := &audit.Event{
+		Type:      eventType,
+		UserID:    userID,
+		ChannelID: channelID,
+		Success:   success,
+		Details:   details,
+	}
+	if err := p.audit.Record(event); err != nil {
+		p.API.LogError("Failed to record audit event", "type", string(eventType), "error", err.Error())
+	}
+}
+
+// handleAuditExport streams audit events as JSON lines to system admins
+func (p *Plugin) handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" || !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	filter := audit.Filter{UserID: r.URL.Query().Get("user_id")}
+	if since := r.URL.Query().Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "Invalid since parameter, expected RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		filter.Since = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="telemost-audit.jsonl"`)
+	if err := p.audit.Export(w, filter); err != nil {
+		p.API.LogError("Failed to export audit events", "error", err.Error())
+	}
+}
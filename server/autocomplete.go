@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const autocompleteMeetingsLimit = 10
+
+// handleAutocompleteMeetings lists the user's active meetings, newest first, for the /telemost
+// command's meeting ID arguments
+func (p *Plugin) handleAutocompleteMeetings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	records, err := p.listIndexedMeetingRecords(meetingCreatorIndexKeyPrefix+userID, func(record *command.MeetingRecord) bool {
+		return record.State == command.MeetingStateActive
+	})
+	if err != nil {
+		p.API.LogError("Failed to list meetings for autocomplete", "user_id", userID, "error", err.Error())
+	}
+	if len(records) > autocompleteMeetingsLimit {
+		records = records[:autocompleteMeetingsLimit]
+	}
+
+	items := []model.AutocompleteListItem{}
+	for _, record := range records {
+		items = append(items, model.AutocompleteListItem{
+			Item:     record.ID,
+			Hint:     record.Title,
+			HelpText: "Started " + record.CreatedAt.UTC().Format("Jan 2 15:04 UTC"),
+		})
+	}
+
+	writeAutocompleteItems(w, items)
+}
+
+// handleAutocompleteTemplates lists the meeting templates for /telemost start --template
+func (p *Plugin) handleAutocompleteTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Mattermost-User-Id") == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	templates, err := p.meetings.GetMeetingTemplates()
+	if err != nil {
+		p.API.LogError("Failed to get meeting templates for autocomplete", "error", err.Error())
+	}
+
+	items := []model.AutocompleteListItem{}
+	for _, template := range templates {
+		items = append(items, model.AutocompleteListItem{
+			Item:     template.Name,
+			HelpText: template.Title,
+		})
+	}
+
+	writeAutocompleteItems(w, items)
+}
+
+func writeAutocompleteItems(w http.ResponseWriter, items []model.AutocompleteListItem) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/pkg/errors"
+)
+
+const (
+	botUsername    = "telemost"
+	botDisplayName = "Telemost"
+	botDescription = "Created by the Telemost plugin."
+
+	connectionPostType           = "custom_telemost_connection"
+	connectionStatusConnected    = "connected"
+	connectionStatusDisconnected = "disconnected"
+)
+
+// ensureBot creates the plugin's bot account if needed and remembers its user ID
+func (p *Plugin) ensureBot() error {
+	botUserID, err := p.client.Bot.EnsureBot(&model.Bot{
+		Username:    botUsername,
+		DisplayName: botDisplayName,
+		Description: botDescription,
+	}, pluginapi.ProfileImagePath("assets/profile.png"))
+	if err != nil {
+		return errors.Wrap(err, "failed to ensure bot")
+	}
+
+	p.botUserID = botUserID
+	return nil
+}
+
+// getBotDirectChannel returns the direct channel between the bot and a user
+func (p *Plugin) getBotDirectChannel(userID string) (*model.Channel, error) {
+	channel, appErr := p.API.GetDirectChannel(userID, p.botUserID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return channel, nil
+}
+
+// sendDirectMessage posts a message from the bot to a user
+func (p *Plugin) sendDirectMessage(userID string, post *model.Post) error {
+	channel, err := p.getBotDirectChannel(userID)
+	if err != nil {
+		return err
+	}
+
+	post.UserId = p.botUserID
+	post.ChannelId = channel.Id
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// sendConnectionStatus tells a user in a DM that their Telemost account was connected or
+// disconnected, rendered by the webapp's connection component
+func (p *Plugin) sendConnectionStatus(userID, status string) {
+	username := p.displayUsername(userID)
+
+	message := "✅ **Connected to Telemost**\n\nYour Telemost authentication has been connected. Use `/telemost start` to create a meeting."
+	if status == connectionStatusDisconnected {
+		message = "**Disconnected from Telemost**\n\nYour Telemost authentication has been removed. Use `/telemost connect` to authenticate again."
+	}
+
+	post := &model.Post{
+		Type:    connectionPostType,
+		Message: message,
+	}
+	post.SetProps(model.StringInterface{
+		"userId":   userID,
+		"username": username,
+		"status":   status,
+	})
+	if err := p.sendDirectMessage(userID, post); err != nil {
+		p.API.LogError("Failed to send connection status", "user_id", userID, "error", err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	callActionAccept  = "accept"
+	callActionDecline = "decline"
+)
+
+// handleCallResponse handles the Accept/Decline buttons of an ad-hoc call card
+func (p *Plugin) handleCallResponse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	action, _ := req.Context["action"].(string)
+	if action != callActionAccept && action != callActionDecline {
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+
+	post, appErr := p.API.GetPost(req.PostId)
+	if appErr != nil {
+		http.Error(w, "Call not found", http.StatusNotFound)
+		return
+	}
+
+	if isCall, _ := post.GetProp("call").(bool); !isCall {
+		http.Error(w, "Post is not a call", http.StatusBadRequest)
+		return
+	}
+
+	if !containsString(propStringSlice(post.GetProp("participants")), userID) {
+		http.Error(w, "Not a participant of this call", http.StatusForbidden)
+		return
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		p.API.LogError("Failed to get user for call response", "user_id", userID, "error", appErr.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// A participant may change their mind, so keep them in one list only
+	accepted := removeString(propStringSlice(post.GetProp("accepted")), user.Username)
+	declined := removeString(propStringSlice(post.GetProp("declined")), user.Username)
+	response := &model.PostActionIntegrationResponse{}
+	if action == callActionAccept {
+		accepted = append(accepted, user.Username)
+		response.EphemeralText = "You accepted the call."
+	} else {
+		declined = append(declined, user.Username)
+		response.EphemeralText = "You declined the call."
+	}
+	post.AddProp("accepted", accepted)
+	post.AddProp("declined", declined)
+
+	updated, appErr := p.API.UpdatePost(post)
+	if appErr != nil {
+		p.API.LogError("Failed to update call post", "post_id", post.Id, "error", appErr.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	response.Update = updated
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// propStringSlice converts a post prop holding a list of strings, which comes back from the
+// database as []interface{}, into a []string.
+func propStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return []string{}
+	}
+}
+
+// containsString reports whether the slice contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns the slice without any occurrence of the value
+func removeString(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
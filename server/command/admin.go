@@ -0,0 +1,167 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const adminTokensListLimit = 200
+
+// ConnectedUser is a user with a stored Telemost token, described without the token
+type ConnectedUser struct {
+	UserID string `json:"user_id"`
+	UserConnection
+}
+
+// handleAdmin runs the token administration for system admins:
+// /telemost admin tokens list|revoke <user>|revoke-all
+func (h *Handler) handleAdmin(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	if len(params) < 2 || params[0] != "tokens" {
+		return adminUsageResponse(), nil
+	}
+
+	switch {
+	case params[1] == "list" && len(params) == 2:
+		return h.handleAdminTokensList()
+	case params[1] == "revoke" && len(params) == 3:
+		return h.handleAdminTokensRevoke(args, params[2])
+	case params[1] == "revoke-all" && len(params) == 2:
+		return h.handleAdminTokensRevokeAll(args)
+	default:
+		return adminUsageResponse(), nil
+	}
+}
+
+func adminUsageResponse() *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         "**Usage:** `/telemost admin tokens list|revoke <@user>|revoke-all`\n\n`list` shows who has connected Telemost, `revoke` disconnects a user and `revoke-all` disconnects everyone. Revoked tokens are also revoked with Yandex.",
+	}
+}
+
+// handleAdminTokensList shows the users with a stored token, most recently connected first
+func (h *Handler) handleAdminTokensList() (*model.CommandResponse, *model.AppError) {
+	users, err := h.admin.ListConnectedUsers()
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to list the connected users!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	if len(users) == 0 {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "No users have connected Telemost.",
+		}, nil
+	}
+
+	var sb strings.Builder
+	if len(users) > adminTokensListLimit {
+		fmt.Fprintf(&sb, "**Connected users** (the %d most recent of %d)\n\n", adminTokensListLimit, len(users))
+		users = users[:adminTokensListLimit]
+	} else {
+		fmt.Fprintf(&sb, "**Connected users** (%d)\n\n", len(users))
+	}
+
+	now := time.Now()
+	sb.WriteString("| User | Connected | Expires |\n|---|---|---|\n")
+	for _, user := range users {
+		connected := "-"
+		if !user.ConnectedAt.IsZero() {
+			connected = user.ConnectedAt.UTC().Format(statusTimeFormat)
+		}
+		expires := user.ExpiresAt.UTC().Format(statusTimeFormat)
+		if user.ExpiresAt.Before(now) {
+			if user.Refreshable {
+				expires += " (expired, refreshable)"
+			} else {
+				expires += " (expired)"
+			}
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", h.displayUser(user.UserID), connected, expires)
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         sb.String(),
+	}, nil
+}
+
+// handleAdminTokensRevoke force-disconnects a user
+func (h *Handler) handleAdminTokensRevoke(args *model.CommandArgs, name string) (*model.CommandResponse, *model.AppError) {
+	user, err := h.lookupUser(name)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("Unknown user: `%s`.", name),
+		}, nil
+	}
+
+	revocation, err := h.admin.RevokeUserToken(args.UserId, user.Id)
+	var notConnected *NotConnectedError
+	switch {
+	case errors.As(err, &notConnected):
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("@%s is not connected to Telemost.", user.Username),
+		}, nil
+	case err != nil:
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to disconnect @%s!**\n\nError: %s", user.Username, err.Error()),
+		}, nil
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         fmt.Sprintf("**✅ Disconnected @%s from Telemost**\n\nTheir Telemost authentication has been removed.%s", user.Username, tokenRevocationText(revocation)),
+	}, nil
+}
+
+// handleAdminTokensRevokeAll force-disconnects every connected user and sums up the revocations
+func (h *Handler) handleAdminTokensRevokeAll(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	revocations, err := h.admin.RevokeAllUserTokens(args.UserId)
+	if err != nil && len(revocations) == 0 {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to disconnect the connected users!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	counts := map[string]int{}
+	failures := []string{}
+	for userID, revocation := range revocations {
+		counts[revocation.Status]++
+		if revocation.Status == TokenRevocationFailed {
+			failures = append(failures, fmt.Sprintf("- %s: %s", h.displayUser(userID), revocation.Error))
+		}
+	}
+	sort.Strings(failures)
+
+	lines := []string{
+		fmt.Sprintf("**✅ Disconnected %d users from Telemost**", len(revocations)),
+		"",
+		fmt.Sprintf("- Revoked with Yandex: %d", counts[TokenRevoked]),
+		fmt.Sprintf("- Queued for a retry: %d", counts[TokenRevocationQueued]),
+		fmt.Sprintf("- Already expired: %d", counts[TokenRevocationExpired]),
+		fmt.Sprintf("- Not revoked: %d", counts[TokenRevocationFailed]),
+	}
+	if len(failures) > 0 {
+		lines = append(lines, "", "**⚠️ Tokens Yandex didn't revoke:**")
+		lines = append(lines, failures...)
+	}
+	if err != nil {
+		lines = append(lines, "", fmt.Sprintf("**❌ Some users couldn't be disconnected:** %s", err.Error()))
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         strings.Join(lines, "\n"),
+	}, nil
+}
@@ -0,0 +1,133 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	auditCommandLimit     = 50
+	auditDefaultLookback  = 7 * 24 * time.Hour
+	auditExportPluginPath = "/plugins/com.mattermost.plugin-telemost/api/v1/audit/export"
+)
+
+// handleAudit shows recent audit events to system admins: /telemost audit [user] [since]
+func (h *Handler) handleAudit(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	filter := audit.Filter{
+		Since: time.Now().Add(-auditDefaultLookback),
+		Limit: auditCommandLimit,
+	}
+
+	for _, param := range params {
+		if since, ok := parseSince(param); ok {
+			filter.Since = since
+			continue
+		}
+
+		user, err := h.client.User.GetByUsername(strings.TrimPrefix(param, "@"))
+		if err != nil {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("Unknown user or time: `%s`. Use `/telemost audit [@user] [7d|24h|2006-01-02]`.", param),
+			}, nil
+		}
+		filter.UserID = user.Id
+	}
+
+	events, err := h.audit.Query(filter)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to load the audit log!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	if len(events) == 0 {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("No audit events since %s.", filter.Since.UTC().Format(time.RFC3339)),
+		}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Telemost audit log** (latest %d since %s)\n\n", len(events), filter.Since.UTC().Format(time.RFC3339))
+	sb.WriteString("| Time (UTC) | Event | User | Result | Details |\n|---|---|---|---|---|\n")
+	for _, event := range events {
+		result := "✅"
+		if !event.Success {
+			result = "❌"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			event.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+			event.Type,
+			h.displayUser(event.UserID),
+			result,
+			formatDetails(event.Details),
+		)
+	}
+
+	exportURL := fmt.Sprintf("%s?since=%s", auditExportPluginPath, filter.Since.UTC().Format(time.RFC3339))
+	if filter.UserID != "" {
+		exportURL += "&user_id=" + filter.UserID
+	}
+	fmt.Fprintf(&sb, "\n[Export as JSON lines](%s)", exportURL)
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         sb.String(),
+	}, nil
+}
+
+// displayUser renders a user ID as @username when possible
+func (h *Handler) displayUser(userID string) string {
+	if userID == "" {
+		return "-"
+	}
+	user, err := h.client.User.Get(userID)
+	if err != nil {
+		return userID
+	}
+	return "@" + user.Username
+}
+
+// parseSince accepts relative durations such as 7d or 24h and dates such as 2006-01-02
+func parseSince(value string) (time.Time, bool) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return time.Now().AddDate(0, 0, -days), true
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return time.Now().Add(-duration), true
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
+
+// formatDetails renders event details as a stable key=value list
+func formatDetails(details map[string]string) string {
+	if len(details) == 0 {
+		return "-"
+	}
+
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.ReplaceAll(details[key], "|", "\\|")
+		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+	}
+	return strings.Join(parts, " ")
+}
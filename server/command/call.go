@@ -0,0 +1,100 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MeetingPostType is the post type used for meeting cards
+	MeetingPostType = "custom_telemost_meeting"
+
+	// callResponseURL is the plugin route handling Accept/Decline actions on call cards
+	callResponseURL = "/plugins/com.mattermost.plugin-telemost/api/v1/calls/respond"
+)
+
+// startCall creates a meeting in a DM or GM channel, adds every other participant as a cohost
+// and posts an "incoming call" card that notifies all of them.
+func (h *Handler) startCall(args *model.CommandArgs, channel *model.Channel, options *startOptions) (*model.CommandResponse, *model.AppError) {
+	caller, err := h.client.User.Get(args.UserId)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Failed to start call!** Could not load your user profile.",
+		}, nil
+	}
+
+	participants, cohosts, err := h.callParticipants(channel.Id, args.UserId)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Failed to start call!** Could not load the channel participants.",
+		}, nil
+	}
+
+	req := &MeetingRequest{
+		UserID:    args.UserId,
+		ChannelID: channel.Id,
+		Title:     "Telemost Call",
+		Cohosts:   cohosts,
+		Source:    "command",
+	}
+	if errResponse := h.applyStartOptions(req, options); errResponse != nil {
+		return errResponse, nil
+	}
+
+	meeting, err := h.meetings.CreateMeeting(req)
+	if err != nil {
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to create meeting!**\n\nError: %s\n\nPlease try again or contact support.", err.Error()),
+		}, nil
+	}
+
+	// Posted by the bot like every meeting card, the caller is in the props
+	post := NewMeetingPost(h.botUserID, args.UserId, channel.Id, meeting, req.Title, fmt.Sprintf("@%s is calling you", caller.Username))
+	post.Message = fmt.Sprintf("📞 Incoming Telemost call from @%s", caller.Username)
+	post.AddProp("call", true)
+	post.AddProp("callerId", args.UserId)
+	post.AddProp("participants", participants)
+	post.AddProp("accepted", []string{})
+	post.AddProp("declined", []string{})
+	post.AddProp("responseURL", callResponseURL)
+	post.AddProp(model.PostPropsForceNotification, true)
+
+	if err := h.client.Post.CreatePost(post); err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to post the call!**\n\nThe meeting was created, you can join it here: %s", meeting.JoinURL),
+		}, nil
+	}
+
+	return &model.CommandResponse{}, nil
+}
+
+// callParticipants returns the user IDs of everyone in the channel except the caller and bots,
+// together with their emails so they can be added as cohosts.
+func (h *Handler) callParticipants(channelID, callerID string) ([]string, []string, error) {
+	members, err := h.client.User.ListInChannel(channelID, model.ChannelSortByUsername, 0, model.ChannelGroupMaxUsers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	participants := []string{}
+	cohosts := []string{}
+	for _, member := range members {
+		if member.Id == callerID || member.IsBot {
+			continue
+		}
+		participants = append(participants, member.Id)
+		if member.Email != "" {
+			cohosts = append(cohosts, member.Email)
+		}
+	}
+
+	return participants, cohosts, nil
+}
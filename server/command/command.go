@@ -1,19 +1,15 @@
 package command
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
 const telemostCommandTrigger = "telemost"
 
-// UserToken represents a user's OAuth token
-type UserToken struct {
-	AccessToken string `json:"access_token"`
-	ExpiresAt   string `json:"expires_at"`
-	UserID      string `json:"user_id"`
-}
-
 // TelemostMeeting represents a Telemost meeting
 type TelemostMeeting struct {
 	ID         string `json:"id"`
@@ -23,18 +19,113 @@ type TelemostMeeting struct {
 	} `json:"live_stream,omitempty"`
 }
 
+// Live stream access levels and the value opting a meeting out of the live stream
+const (
+	LiveStreamPublic       = "PUBLIC"
+	LiveStreamOrganization = "ORGANIZATION"
+	LiveStreamOff          = "off"
+)
+
+// MeetingRequest describes a meeting to create on behalf of a user
+type MeetingRequest struct {
+	UserID      string
+	ChannelID   string
+	Title       string
+	Description string
+	Cohosts     []string
+
+	// LiveStream is an access level to stream the meeting, LiveStreamOff to not stream it, or
+	// empty for the configured default
+	LiveStream string
+
+	// WaitingRoomLevel overrides the configured default waiting room level when set
+	WaitingRoomLevel string
+
+	// Source is the entry point creating the meeting, recorded in the audit log and events
+	Source string
+
+	// Persistent exempts the meeting from the auto-end policy
+	Persistent bool
+}
+
+// MeetingTemplateInfo describes a meeting template admins configured
+type MeetingTemplateInfo struct {
+	Name  string
+	Title string
+}
+
+// LiveStreamRequest starts or stops the live stream of an existing meeting
+type LiveStreamRequest struct {
+	UserID    string
+	ChannelID string
+	MeetingID string
+
+	// AccessLevel is the access level to start the stream with, empty for the configured default,
+	// or LiveStreamOff to stop it
+	AccessLevel string
+}
+
+// RateLimitError is returned when a user or channel has created too many meetings recently
+type RateLimitError struct {
+	Scope      string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s meeting rate limit of %d per hour reached, retry in %s", e.Scope, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// PermissionError is returned when the plugin's restrictions don't allow creating a meeting
+type PermissionError struct {
+	Reason string
+}
+
+func (e *PermissionError) Error() string {
+	return "not allowed to create a meeting: " + e.Reason
+}
+
+// NotConnectedError is returned when a user needs to connect their Telemost account first
+type NotConnectedError struct{}
+
+func (e *NotConnectedError) Error() string {
+	return "not connected to Telemost"
+}
+
+// UnsupportedError is returned when the meeting provider doesn't support a feature
+type UnsupportedError struct {
+	Provider string
+	Feature  string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s doesn't support %s", e.Provider, e.Feature)
+}
+
+// TemplateNotFoundError is returned when a meeting template doesn't exist
+type TemplateNotFoundError struct {
+	Name string
+}
+
+func (e *TemplateNotFoundError) Error() string {
+	return fmt.Sprintf("meeting template %q not found", e.Name)
+}
+
 // RegisterCommand registers the telemost slash command
 func RegisterCommand(client *pluginapi.Client) error {
 	// Base64 encoded Telemost icon SVG with data URL prefix
 	iconData := "data:image/svg+xml;base64,PHN2ZyB3aWR0aD0iMzIiIGhlaWdodD0iMzIiIGZpbGw9Im5vbmUiIHhtbG5zPSJodHRwOi8vd3d3LnczLm9yZy8yMDAwL3N2ZyI+PHBhdGggZD0iTTAgMTZDMCA5LjQ0IDAgNi4xNiAxLjYzIDMuODRhOSA5IDAgMCAxIDIuMi0yLjIxQzYuMTcgMCA5LjQ1IDAgMTYgMHM5Ljg0IDAgMTIuMTYgMS42M2E5IDkgMCAwIDEgMi4yMSAyLjJDMzIgNi4xNyAzMiA5LjQ1IDMyIDE2czAgOS44NC0xLjYzIDEyLjE2YTkgOSAwIDAgMS0yLjIgMi4yMUMyNS44MyAzMiAyMi41NSAzMiAxNiAzMnMtOS44NCAwLTEyLjE2LTEuNjNhOSA5IDAgMCAxLTIuMjEtMi4yQzAgMjUuODMgMCAyMi41NSAwIDE2eiIgZmlsbD0idXJsKCNhKSIvPjxjaXJjbGUgb3BhY2l0eT0iLjYiIGN4PSIxNC4xNyIgY3k9IjEzLjUiIHI9IjEuNSIgZmlsbD0iI2ZmZiIvPjxjaXJjbGUgY3g9IjEyIiBjeT0iMTYiIHI9IjciIHN0cm9rZT0iI2ZmZiIgc3Ryb2tlLXdpZHRoPSIxLjUiLz48Y2lyY2xlIGN4PSIyNSIgY3k9IjE2IiBmaWxsPSIjMEYwIiByPSIzIi8+PGRlZnM+PGxpbmVhckdyYWRpZW50IGlkPSJhIiB4MT0iLTMuMjciIHkxPSIzNS42NCIgeDI9IjMyIiB5Mj0iMCIgZ3JhZGllbnRVbml0cz0idXNlclNwYWNlT25Vc2UiPjxzdG9wLz48c3RvcCBvZmZzZXQ9IjEiIHN0b3AtY29sb3I9IiMzMTQwNEUiLz48L2xpbmVhckdyYWRpZW50PjwvZGVmcz48L3N2Zz4="
 
+	// The autocomplete tree is generated from the subcommands, see router.go
+	autocompleteData := newRouter().autocompleteData()
+
 	cmd := &model.Command{
 		Trigger:              telemostCommandTrigger,
 		AutoComplete:         true,
-		AutoCompleteDesc:     "Available commands: start | connect | disconnect | help",
+		AutoCompleteDesc:     autocompleteData.HelpText,
 		AutoCompleteHint:     "[command]",
 		AutocompleteIconData: iconData,
-		AutocompleteData:     model.NewAutocompleteData(telemostCommandTrigger, "[command]", "Available commands: start | connect | disconnect | help"),
+		AutocompleteData:     autocompleteData,
 	}
 
 	client.Log.Info("RegisterCommand: About to register command", "trigger", telemostCommandTrigger)
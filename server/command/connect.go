@@ -0,0 +1,84 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Outcomes of revoking a user's token with Yandex when it is removed
+const (
+	TokenRevoked           = "revoked"
+	TokenRevocationQueued  = "queued"
+	TokenRevocationFailed  = "failed"
+	TokenRevocationExpired = "expired"
+)
+
+// TokenRevocation is the outcome of revoking a user's token with Yandex
+type TokenRevocation struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleConnect sends the user the link to connect their Telemost account
+func (h *Handler) handleConnect(args *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	// Check if user is already authenticated
+	if h.auth.IsConnected(args.UserId) {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**✅ Already Connected to Telemost**\n\nYou are already authenticated with Telemost. You can:\n- Use `/telemost start` to create a meeting\n- Use `/telemost disconnect` to remove authentication",
+		}, nil
+	}
+
+	// Start OAuth authentication flow
+	oauthURL := h.auth.GetConnectURL(args.ChannelId)
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         fmt.Sprintf("**🔗 Telemost Authentication Required**\n\nTo connect to Telemost, please complete the OAuth authentication:\n\n[**Click here to authenticate with Telemost**](%s)\n\nAfter authentication, you'll be able to create meetings using `/telemost start`.", oauthURL),
+	}, nil
+}
+
+// handleDisconnect removes the user's Telemost token
+func (h *Handler) handleDisconnect(args *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	// Check if user is authenticated before trying to disconnect
+	if !h.auth.IsConnected(args.UserId) {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**Not authenticated!** You are not currently authenticated with Telemost. Use `/telemost connect` to authenticate first.",
+		}, nil
+	}
+
+	// Revoke and remove OAuth token
+	revocation, err := h.auth.DisconnectUser(args.UserId)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Failed to disconnect!** There was an error removing your authentication. Please try again.",
+		}, nil
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         "**✅ Disconnected from Telemost**\n\nYour Telemost authentication has been removed." + tokenRevocationText(revocation) + "\n\nUse `/telemost connect` to authenticate again.",
+	}, nil
+}
+
+// tokenRevocationText explains what happened to a removed token on the Yandex side, as a sentence
+// to append to a message
+func tokenRevocationText(revocation *TokenRevocation) string {
+	if revocation == nil {
+		return ""
+	}
+
+	switch revocation.Status {
+	case TokenRevoked:
+		return " The token was revoked with Yandex."
+	case TokenRevocationQueued:
+		return fmt.Sprintf(" Yandex couldn't be reached to revoke the token (%s), the revocation will be retried in the background.", revocation.Error)
+	case TokenRevocationExpired:
+		return " The token had already expired."
+	default:
+		return fmt.Sprintf("\n\n**⚠️ The token couldn't be revoked with Yandex:** %s", revocation.Error)
+	}
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// DiagnosticStatus is the outcome of a diagnostic check
+type DiagnosticStatus string
+
+const (
+	DiagnosticOK      DiagnosticStatus = "ok"
+	DiagnosticWarning DiagnosticStatus = "warning"
+	DiagnosticError   DiagnosticStatus = "error"
+)
+
+// DiagnosticCheck is the result of one check run by /telemost diagnostics
+type DiagnosticCheck struct {
+	Name    string
+	Status  DiagnosticStatus
+	Details string
+}
+
+// DiagnosticRecordCount is the number of KV records of one kind
+type DiagnosticRecordCount struct {
+	Name  string
+	Count int
+}
+
+// Diagnostics is the report shown by /telemost diagnostics
+type Diagnostics struct {
+	Checks  []DiagnosticCheck
+	Records []DiagnosticRecordCount
+}
+
+// handleDiagnostics checks the configuration and connectivity for system admins
+func (h *Handler) handleDiagnostics(_ *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	diagnostics := h.admin.RunDiagnostics()
+
+	var sb strings.Builder
+	sb.WriteString("**Telemost diagnostics**\n\n")
+	sb.WriteString("| Check | Status | Details |\n|---|---|---|\n")
+	for _, check := range diagnostics.Checks {
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", check.Name, diagnosticStatusIcon(check.Status), strings.ReplaceAll(check.Details, "|", "\\|"))
+	}
+
+	if len(diagnostics.Records) > 0 {
+		sb.WriteString("\n**KV records**\n\n| Records | Count |\n|---|---|\n")
+		for _, record := range diagnostics.Records {
+			fmt.Fprintf(&sb, "| %s | %d |\n", record.Name, record.Count)
+		}
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         sb.String(),
+	}, nil
+}
+
+func diagnosticStatusIcon(status DiagnosticStatus) string {
+	switch status {
+	case DiagnosticOK:
+		return "✅"
+	case DiagnosticWarning:
+		return "⚠️"
+	default:
+		return "❌"
+	}
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// handleEnd ends a meeting for all participants, by default the user's last one:
+// /telemost end [meeting-id]
+func (h *Handler) handleEnd(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	if len(params) > 1 {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**Usage:** `/telemost end [meeting-id]`\n\nWithout a meeting ID, your last meeting is ended.",
+		}, nil
+	}
+
+	meetingID, errResponse := h.targetMeetingID(args.UserId, params)
+	if errResponse != nil {
+		return errResponse, nil
+	}
+
+	if err := h.meetings.EndMeeting(args.UserId, args.ChannelId, meetingID); err != nil {
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to end the meeting!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         fmt.Sprintf("**✅ Meeting ended**\n\nMeeting `%s` has ended for all participants.", meetingID),
+	}, nil
+}
+
+// targetMeetingID returns the meeting ID given as the only parameter, or the ID of the user's last
+// meeting, or the response explaining that there is no meeting
+func (h *Handler) targetMeetingID(userID string, params []string) (string, *model.CommandResponse) {
+	if len(params) == 1 {
+		return params[0], nil
+	}
+
+	lastMeeting, err := h.meetings.GetLastMeeting(userID)
+	if err != nil || lastMeeting == nil {
+		return "", &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ No meeting found!** Start a meeting with `/telemost start` or pass a meeting ID.",
+		}
+	}
+	return lastMeeting.ID, nil
+}
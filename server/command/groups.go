@@ -0,0 +1,27 @@
+package command
+
+import (
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const groupMembersPerPage = 200
+
+// GroupMembers returns the active human members of a user group, loading all pages
+func GroupMembers(client *pluginapi.Client, groupID string) ([]*model.User, error) {
+	members := []*model.User{}
+	for page := 0; ; page++ {
+		users, err := client.Group.GetMemberUsers(groupID, page, groupMembersPerPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if !user.IsBot && user.DeleteAt == 0 {
+				members = append(members, user)
+			}
+		}
+		if len(users) < groupMembersPerPage {
+			return members, nil
+		}
+	}
+}
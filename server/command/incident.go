@@ -0,0 +1,237 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// DefaultIncidentChannelNameTemplate is used when admins haven't configured a template
+	DefaultIncidentChannelNameTemplate = "incident-{{date}}-{{name}}"
+)
+
+var channelNameInvalidChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// handleIncident creates a private war-room channel, invites the given users and groups, starts
+// a meeting with them as cohosts, pins the meeting card and puts the join link in the header:
+// /telemost incident <title> [@user|@group ...]
+func (h *Handler) handleIncident(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	if args.TeamId == "" {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Failed to start incident!** Run this command from a team channel.",
+		}, nil
+	}
+
+	// Incident channels are created by the plugin, so team policy on private channels is
+	// enforced here
+	if !h.client.User.HasPermissionToTeam(args.UserId, args.TeamId, model.PermissionCreatePrivateChannel) {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Permission denied!** You are not allowed to create private channels in this team.",
+		}, nil
+	}
+
+	titleWords := []string{}
+	mentions := []string{}
+	for _, param := range params {
+		if strings.HasPrefix(param, "@") {
+			mentions = append(mentions, strings.TrimPrefix(param, "@"))
+		} else {
+			titleWords = append(titleWords, param)
+		}
+	}
+	title := strings.Join(titleWords, " ")
+	if title == "" {
+		title = "Incident"
+	}
+
+	invitees, err := h.resolveInvitees(mentions)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to start incident!** %s", err.Error()),
+		}, nil
+	}
+
+	// Check the restrictions and the connection against the incident channel before creating it,
+	// so it isn't left without a meeting
+	channel := h.newIncidentChannel(args, title)
+	if err := h.auth.CheckMeetingCreation(args.UserId, channel); err != nil {
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to start incident!** %s", err.Error()),
+		}, nil
+	}
+
+	if err := h.createIncidentChannel(args, channel); err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to create the incident channel!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	cohosts := []string{}
+	for _, invitee := range invitees {
+		if invitee.Email != "" {
+			cohosts = append(cohosts, invitee.Email)
+		}
+	}
+
+	// Create the meeting before inviting anyone, so a denied or failed meeting only leaves an
+	// archived channel behind
+	meeting, err := h.meetings.CreateMeeting(&MeetingRequest{
+		UserID:    args.UserId,
+		ChannelID: channel.Id,
+		Title:     title,
+		Cohosts:   cohosts,
+		Source:    "command",
+	})
+	if err != nil {
+		if deleteErr := h.client.Channel.Delete(channel.Id); deleteErr != nil {
+			h.client.Log.Error("Failed to archive incident channel", "channel_id", channel.Id, "error", deleteErr.Error())
+		}
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to create meeting!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	failedInvites := []string{}
+	for _, invitee := range invitees {
+		if _, err := h.client.Channel.AddUser(channel.Id, invitee.Id, args.UserId); err != nil {
+			failedInvites = append(failedInvites, "@"+invitee.Username)
+		}
+	}
+
+	post := h.newMeetingPost(args.UserId, channel.Id, meeting, title)
+	post.IsPinned = true
+	post.AddProp("pretext", "Incident bridge")
+	if err := h.client.Post.CreatePost(post); err != nil {
+		h.client.Log.Error("Failed to post incident meeting", "channel_id", channel.Id, "error", err.Error())
+	}
+
+	channel.Header = fmt.Sprintf("📞 Incident bridge: [Join meeting](%s)", meeting.JoinURL)
+	if err := h.client.Channel.Update(channel); err != nil {
+		h.client.Log.Error("Failed to set incident channel header", "channel_id", channel.Id, "error", err.Error())
+	}
+
+	text := fmt.Sprintf("**🚨 Incident started:** ~%s\n\n[Join meeting](%s)", channel.Name, meeting.JoinURL)
+	if len(failedInvites) > 0 {
+		text += fmt.Sprintf("\n\nCould not invite: %s", strings.Join(failedInvites, ", "))
+	}
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         text,
+	}, nil
+}
+
+// resolveInvitees turns @mentions into users, expanding user groups into their members
+func (h *Handler) resolveInvitees(mentions []string) ([]*model.User, error) {
+	seen := map[string]bool{}
+	invitees := []*model.User{}
+	add := func(user *model.User) {
+		if !seen[user.Id] && !user.IsBot && user.DeleteAt == 0 {
+			seen[user.Id] = true
+			invitees = append(invitees, user)
+		}
+	}
+
+	for _, mention := range mentions {
+		if user, err := h.client.User.GetByUsername(mention); err == nil {
+			add(user)
+			continue
+		}
+
+		group, err := h.client.Group.GetByName(mention)
+		if err != nil {
+			return nil, fmt.Errorf("`@%s` is neither a user nor a group", mention)
+		}
+		members, err := GroupMembers(h.client, group.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load members of `@%s`", mention)
+		}
+		for _, member := range members {
+			add(member)
+		}
+	}
+
+	return invitees, nil
+}
+
+// newIncidentChannel describes the private channel to create for an incident, named from the
+// configured template
+func (h *Handler) newIncidentChannel(args *model.CommandArgs, title string) *model.Channel {
+	now := time.Now()
+	if user, err := h.client.User.Get(args.UserId); err == nil {
+		now = UserTime(user, now)
+	}
+	name := renderIncidentChannelName(h.meetings.GetIncidentChannelNameTemplate(), title, now)
+	if existing, err := h.client.Channel.GetByName(args.TeamId, name, true); err == nil && existing != nil {
+		suffix := "-" + model.NewId()[:6]
+		name = strings.TrimRight(name[:min(len(name), model.ChannelNameMaxLength-len(suffix))], "-") + suffix
+	}
+
+	channel := &model.Channel{
+		TeamId:      args.TeamId,
+		Type:        model.ChannelTypePrivate,
+		Name:        name,
+		DisplayName: fmt.Sprintf("Incident: %s", title),
+		Purpose:     fmt.Sprintf("War room for %s", title),
+		CreatorId:   args.UserId,
+	}
+	if len([]rune(channel.DisplayName)) > model.ChannelDisplayNameMaxRunes {
+		channel.DisplayName = string([]rune(channel.DisplayName)[:model.ChannelDisplayNameMaxRunes])
+	}
+	return channel
+}
+
+// createIncidentChannel creates the incident channel and adds the incident commander to it as
+// channel admin
+func (h *Handler) createIncidentChannel(args *model.CommandArgs, channel *model.Channel) error {
+	if err := h.client.Channel.Create(channel); err != nil {
+		return err
+	}
+
+	if _, err := h.client.Channel.AddMember(channel.Id, args.UserId); err != nil {
+		if deleteErr := h.client.Channel.Delete(channel.Id); deleteErr != nil {
+			h.client.Log.Error("Failed to archive incident channel", "channel_id", channel.Id, "error", deleteErr.Error())
+		}
+		return err
+	}
+	if _, err := h.client.Channel.UpdateChannelMemberRoles(channel.Id, args.UserId, model.ChannelUserRoleId+" "+model.ChannelAdminRoleId); err != nil {
+		h.client.Log.Warn("Failed to make incident commander channel admin", "channel_id", channel.Id, "error", err.Error())
+	}
+	return nil
+}
+
+// renderIncidentChannelName expands {{name}} and the time placeholders and makes the result a
+// valid channel name
+func renderIncidentChannelName(template, title string, now time.Time) string {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultIncidentChannelNameTemplate
+	}
+
+	values := TimePlaceholders(now)
+	values["name"] = title
+	name := RenderPlaceholders(template, values)
+
+	name = strings.Trim(channelNameInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-_")
+	if len(name) > model.ChannelNameMaxLength {
+		name = strings.TrimRight(name[:model.ChannelNameMaxLength], "-_")
+	}
+	if len(name) < 2 {
+		name = "incident-" + now.Format("2006-01-02-1504")
+	}
+	return name
+}
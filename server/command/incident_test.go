@@ -0,0 +1,65 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderIncidentChannelName(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 14, 30, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		template string
+		title    string
+		expected string
+	}{
+		"default template": {
+			title:    "Database outage",
+			expected: "incident-2024-03-01-database-outage",
+		},
+		"blank template uses the default": {
+			template: "   ",
+			title:    "outage",
+			expected: "incident-2024-03-01-outage",
+		},
+		"time and weekday": {
+			template: "war-room-{{weekday}}-{{time}}",
+			expected: "war-room-friday-14-30",
+		},
+		"spaces inside the braces": {
+			template: "inc-{{ name }}",
+			title:    "API",
+			expected: "inc-api",
+		},
+		"invalid characters become dashes": {
+			template: "{{name}}",
+			title:    "Payments: 500s & timeouts!",
+			expected: "payments-500s-timeouts",
+		},
+		"unknown placeholders expand to nothing": {
+			template: "inc-{{unknown}}-{{name}}",
+			title:    "db",
+			expected: "inc--db",
+		},
+		"long names are truncated without trailing dashes": {
+			template: "{{name}}",
+			title:    strings.Repeat("a", model.ChannelNameMaxLength-1) + " b",
+			expected: strings.Repeat("a", model.ChannelNameMaxLength-1),
+		},
+		"falls back when nothing valid is left": {
+			template: "{{name}}",
+			title:    "!!!",
+			expected: "incident-2024-03-01-1430",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rendered := renderIncidentChannelName(tc.template, tc.title, now)
+			assert.Equal(t, tc.expected, rendered)
+			assert.LessOrEqual(t, len(rendered), model.ChannelNameMaxLength)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Meeting states
+const (
+	MeetingStateActive = "active"
+	MeetingStateEnded  = "ended"
+)
+
+// Meeting list scopes
+const (
+	MeetingListChannel = "channel"
+	MeetingListMine    = "mine"
+	MeetingListAll     = "all"
+)
+
+// Actions of the /telemost list buttons
+const (
+	MeetingListActionPage   = "page"
+	MeetingListActionRejoin = "rejoin"
+	MeetingListActionEnd    = "end"
+)
+
+const (
+	meetingListPerPage = 10
+
+	// MeetingListActionPath is the plugin route handling the /telemost list buttons
+	MeetingListActionPath = "/api/v1/meetings/list"
+	meetingListActionURL  = "/plugins/com.mattermost.plugin-telemost" + MeetingListActionPath
+)
+
+// MeetingRecord is a meeting created through the plugin
+type MeetingRecord struct {
+	ID        string    `json:"id"`
+	JoinURL   string    `json:"join_url"`
+	Title     string    `json:"title"`
+	CreatorID string    `json:"creator_id"`
+	ChannelID string    `json:"channel_id"`
+	PostID    string    `json:"post_id,omitempty"`
+	Provider  string    `json:"provider"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	EndedAt   time.Time `json:"ended_at"`
+
+	// Persistent meetings are never ended by the auto-end policy
+	Persistent bool `json:"persistent,omitempty"`
+}
+
+// MeetingListQuery selects a page of meeting records, newest first
+type MeetingListQuery struct {
+	UserID    string
+	ChannelID string
+	Scope     string
+	Page      int
+	PerPage   int
+}
+
+// MeetingListItem is a listed meeting and whether its provider can end it
+type MeetingListItem struct {
+	MeetingRecord
+	CanEnd bool
+}
+
+// MeetingList is a page of meeting records and the number of records matching the query
+type MeetingList struct {
+	Meetings []MeetingListItem
+	Total    int
+}
+
+// handleList lists the meetings created through the plugin: /telemost list [--channel|--mine|--all]
+func (h *Handler) handleList(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	scope := MeetingListChannel
+	if len(params) > 1 {
+		return listUsageResponse(), nil
+	}
+	if len(params) == 1 {
+		switch strings.ToLower(params[0]) {
+		case "--channel":
+			scope = MeetingListChannel
+		case "--mine":
+			scope = MeetingListMine
+		case "--all":
+			scope = MeetingListAll
+		default:
+			return listUsageResponse(), nil
+		}
+	}
+
+	if scope == MeetingListAll && !h.client.User.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**❌ Permission denied!** Only system administrators can list all meetings.",
+		}, nil
+	}
+
+	text, attachments, err := h.RenderMeetingList(args.UserId, args.ChannelId, scope, 0)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to list meetings!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         text,
+		Attachments:  attachments,
+	}, nil
+}
+
+func listUsageResponse() *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         "**Usage:** `/telemost list [--channel|--mine|--all]`\n\nWithout an option, the meetings of this channel are listed.",
+	}
+}
+
+// RenderMeetingList renders a page of the meeting list, with buttons to rejoin or end each active
+// meeting and to move between pages. It is shared by /telemost list and its buttons.
+func (h *Handler) RenderMeetingList(userID, channelID, scope string, page int) (string, []*model.SlackAttachment, error) {
+	list, err := h.meetings.ListMeetings(&MeetingListQuery{
+		UserID:    userID,
+		ChannelID: channelID,
+		Scope:     scope,
+		Page:      page,
+		PerPage:   meetingListPerPage,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	heading := map[string]string{
+		MeetingListChannel: "Meetings in this channel",
+		MeetingListMine:    "Your meetings",
+		MeetingListAll:     "All meetings",
+	}[scope]
+	if list.Total == 0 {
+		return fmt.Sprintf("**%s**\n\nNo meetings found. Use `/telemost start` to create one.", heading), nil, nil
+	}
+
+	pages := (list.Total + meetingListPerPage - 1) / meetingListPerPage
+	text := fmt.Sprintf("**%s** (page %d of %d)", heading, page+1, pages)
+
+	attachments := []*model.SlackAttachment{}
+	for _, meeting := range list.Meetings {
+		attachments = append(attachments, h.meetingAttachment(&meeting, scope, page))
+	}
+
+	navigation := []*model.PostAction{}
+	if page > 0 {
+		navigation = append(navigation, meetingListAction("Previous", "default", MeetingListActionPage, "", scope, page-1))
+	}
+	if page+1 < pages {
+		navigation = append(navigation, meetingListAction("Next", "default", MeetingListActionPage, "", scope, page+1))
+	}
+	if len(navigation) > 0 {
+		attachments = append(attachments, &model.SlackAttachment{Actions: navigation})
+	}
+
+	return text, attachments, nil
+}
+
+// meetingAttachment describes a listed meeting
+func (h *Handler) meetingAttachment(meeting *MeetingListItem, scope string, page int) *model.SlackAttachment {
+	state := "🟢 Active"
+	if meeting.State == MeetingStateEnded {
+		state = "⚪ Ended"
+	} else if meeting.Persistent {
+		state = "📌 Persistent"
+	}
+
+	attachment := &model.SlackAttachment{
+		Title: meeting.Title,
+		Text: fmt.Sprintf("%s · started by %s in %s · %s", state, h.displayUser(meeting.CreatorID),
+			h.displayChannel(meeting.ChannelID), meeting.CreatedAt.UTC().Format(statusTimeFormat)),
+	}
+	if meeting.State == MeetingStateActive {
+		attachment.TitleLink = meeting.JoinURL
+		attachment.Actions = append(attachment.Actions, meetingListAction("Rejoin", "primary", MeetingListActionRejoin, meeting.ID, scope, page))
+		if meeting.CanEnd {
+			attachment.Actions = append(attachment.Actions, meetingListAction("End", "danger", MeetingListActionEnd, meeting.ID, scope, page))
+		}
+	}
+	return attachment
+}
+
+// meetingListAction builds a button of the meeting list. The scope and page let the plugin
+// render the list again after handling the button.
+func meetingListAction(name, style, action, meetingID, scope string, page int) *model.PostAction {
+	return &model.PostAction{
+		Type:  model.PostActionTypeButton,
+		Name:  name,
+		Style: style,
+		Integration: &model.PostActionIntegration{
+			URL: meetingListActionURL,
+			Context: map[string]interface{}{
+				"action":     action,
+				"meeting_id": meetingID,
+				"scope":      scope,
+				"page":       page,
+			},
+		},
+	}
+}
+
+// displayChannel names a channel for display, falling back to its ID
+func (h *Handler) displayChannel(channelID string) string {
+	channel, err := h.client.Channel.Get(channelID)
+	if err != nil {
+		return channelID
+	}
+	if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
+		return "a direct message"
+	}
+	return "~" + channel.Name
+}
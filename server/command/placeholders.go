@@ -0,0 +1,40 @@
+package command
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// PlaceholderPattern matches a {{placeholder}} in text configured by admins, such as meeting
+// template titles and incident channel names
+var PlaceholderPattern = regexp.MustCompile(`{{\s*([a-z_]+)\s*}}`)
+
+// TimePlaceholders returns the values of {{date}}, {{time}} and {{weekday}}, so they expand the
+// same way wherever they are used
+func TimePlaceholders(now time.Time) map[string]string {
+	return map[string]string{
+		"date":    now.Format("2006-01-02"),
+		"time":    now.Format("15:04"),
+		"weekday": now.Format("Monday"),
+	}
+}
+
+// UserTime returns the time in the user's timezone, or unchanged if it isn't known
+func UserTime(user *model.User, now time.Time) time.Time {
+	if location, err := time.LoadLocation(model.GetPreferredTimezone(user.Timezone)); err == nil {
+		return now.In(location)
+	}
+	return now
+}
+
+// RenderPlaceholders expands the {{placeholder}}s in text. Placeholders without a value expand to
+// an empty string.
+func RenderPlaceholders(text string, values map[string]string) string {
+	rendered := PlaceholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return values[PlaceholderPattern.FindStringSubmatch(match)[1]]
+	})
+	return strings.TrimSpace(rendered)
+}
@@ -0,0 +1,261 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Plugin routes serving dynamic autocomplete lists, relative to the plugin's URL
+const (
+	AutocompleteMeetingsPath  = "/autocomplete/meetings"
+	AutocompleteTemplatesPath = "/autocomplete/templates"
+)
+
+// subcommand is a /telemost subcommand with everything needed to run it, describe it in the help
+// and build its autocomplete
+type subcommand struct {
+	Name     string
+	Hint     string
+	HelpText string
+
+	// AdminOnly restricts the subcommand to system admins, in the autocomplete as well
+	AdminOnly bool
+
+	// Autocomplete adds the subcommand's arguments and nested commands to its autocomplete data
+	Autocomplete func(data *model.AutocompleteData)
+
+	Execute func(h *Handler, args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError)
+}
+
+// router dispatches /telemost subcommands and generates the help and autocomplete from them
+type router struct {
+	subcommands []*subcommand
+	byName      map[string]*subcommand
+}
+
+// newRouter registers the /telemost subcommands in the order they are listed in the help
+func newRouter() *router {
+	r := &router{byName: map[string]*subcommand{}}
+
+	r.add(&subcommand{
+		Name:         "start",
+		Hint:         "[--template <name>] [--stream public|org|off] [--persistent]",
+		HelpText:     "Start a new meeting (requires authentication)",
+		Autocomplete: autocompleteStart,
+		Execute:      (*Handler).handleStart,
+	})
+	r.add(&subcommand{
+		Name:     "templates",
+		HelpText: "List the meeting templates",
+		Execute:  (*Handler).handleTemplates,
+	})
+	r.add(&subcommand{
+		Name:     "connect",
+		HelpText: "Authenticate with Telemost OAuth",
+		Execute:  (*Handler).handleConnect,
+	})
+	r.add(&subcommand{
+		Name:     "disconnect",
+		HelpText: "Remove Telemost authentication",
+		Execute:  (*Handler).handleDisconnect,
+	})
+	r.add(&subcommand{
+		Name:     "status",
+		HelpText: "Show your connection and meeting defaults",
+		Execute:  (*Handler).handleStatus,
+	})
+	r.add(&subcommand{
+		Name:         "stream",
+		Hint:         "start|stop|info",
+		HelpText:     "Manage the live stream of your last meeting",
+		Autocomplete: autocompleteStream,
+		Execute:      (*Handler).handleStream,
+	})
+	r.add(&subcommand{
+		Name:         "list",
+		Hint:         "[--channel|--mine|--all]",
+		HelpText:     "List recent and active meetings, of this channel by default",
+		Autocomplete: autocompleteList,
+		Execute:      (*Handler).handleList,
+	})
+	r.add(&subcommand{
+		Name:         "end",
+		Hint:         "[meeting-id]",
+		HelpText:     "End your last meeting for everyone",
+		Autocomplete: autocompleteMeetingArgument,
+		Execute:      (*Handler).handleEnd,
+	})
+	r.add(&subcommand{
+		Name:         "incident",
+		Hint:         "<title> [@user|@group ...]",
+		HelpText:     "Create a private war-room channel with a meeting",
+		Autocomplete: autocompleteIncident,
+		Execute:      (*Handler).handleIncident,
+	})
+	r.add(&subcommand{
+		Name:      "audit",
+		Hint:      "[@user] [since]",
+		HelpText:  "Show the audit log",
+		AdminOnly: true,
+		Execute:   (*Handler).handleAudit,
+	})
+	r.add(&subcommand{
+		Name:         "user",
+		Hint:         "export|purge <@user>",
+		HelpText:     "Export or purge the data stored about a user",
+		AdminOnly:    true,
+		Autocomplete: autocompleteUser,
+		Execute:      (*Handler).handleUser,
+	})
+	r.add(&subcommand{
+		Name:         "admin",
+		Hint:         "tokens list|revoke <@user>|revoke-all",
+		HelpText:     "List who has connected Telemost and force-disconnect users",
+		AdminOnly:    true,
+		Autocomplete: autocompleteAdmin,
+		Execute:      (*Handler).handleAdmin,
+	})
+	r.add(&subcommand{
+		Name:      "diagnostics",
+		HelpText:  "Check the configuration and connectivity",
+		AdminOnly: true,
+		Execute:   (*Handler).handleDiagnostics,
+	})
+	r.add(&subcommand{
+		Name:     "help",
+		HelpText: "Show this help message",
+		Execute:  (*Handler).handleHelp,
+	})
+
+	return r
+}
+
+func (r *router) add(cmd *subcommand) {
+	r.subcommands = append(r.subcommands, cmd)
+	r.byName[cmd.Name] = cmd
+}
+
+// get returns the subcommand with the given name, or nil
+func (r *router) get(name string) *subcommand {
+	return r.byName[strings.ToLower(name)]
+}
+
+// names returns the names of all subcommands
+func (r *router) names() []string {
+	names := make([]string, 0, len(r.subcommands))
+	for _, cmd := range r.subcommands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// usage describes a subcommand for the help
+func (cmd *subcommand) usage() string {
+	usage := fmt.Sprintf("- `/%s %s", telemostCommandTrigger, cmd.Name)
+	if cmd.Hint != "" {
+		usage += " " + cmd.Hint
+	}
+	usage += "` - " + cmd.HelpText
+	if cmd.AdminOnly {
+		usage += " (system admins only)"
+	}
+	return usage
+}
+
+// helpText lists the subcommands available to the user
+func (r *router) helpText(isAdmin bool) string {
+	lines := []string{"**Available commands:**"}
+	for _, cmd := range r.subcommands {
+		if cmd.AdminOnly && !isAdmin {
+			continue
+		}
+		lines = append(lines, cmd.usage())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// autocompleteData builds the autocomplete tree of the /telemost command
+func (r *router) autocompleteData() *model.AutocompleteData {
+	root := model.NewAutocompleteData(telemostCommandTrigger, "[command]", "Available commands: "+strings.Join(r.names(), " | "))
+	for _, cmd := range r.subcommands {
+		data := model.NewAutocompleteData(cmd.Name, cmd.Hint, cmd.HelpText)
+		if cmd.AdminOnly {
+			data.RoleID = model.SystemAdminRoleId
+		}
+		if cmd.Autocomplete != nil {
+			cmd.Autocomplete(data)
+		}
+		root.AddCommand(data)
+	}
+	return root
+}
+
+func autocompleteStart(data *model.AutocompleteData) {
+	data.AddNamedDynamicListArgument("template", "Meeting template to start from", AutocompleteTemplatesPath, false)
+	data.AddNamedStaticListArgument("stream", "Stream the meeting, or don't", false, []model.AutocompleteListItem{
+		{Item: "public", HelpText: "Stream for everyone"},
+		{Item: "org", HelpText: "Stream for your organization"},
+		{Item: "off", HelpText: "Don't stream"},
+	})
+}
+
+func autocompleteStream(data *model.AutocompleteData) {
+	start := model.NewAutocompleteData("start", "[public|org] [meeting-id]", "Start a live stream")
+	start.AddStaticListArgument("Access level", false, []model.AutocompleteListItem{
+		{Item: "public", HelpText: "Stream for everyone"},
+		{Item: "org", HelpText: "Stream for your organization"},
+	})
+	autocompleteMeetingArgument(start)
+	data.AddCommand(start)
+
+	stop := model.NewAutocompleteData("stop", "[meeting-id]", "Stop a live stream")
+	autocompleteMeetingArgument(stop)
+	data.AddCommand(stop)
+
+	info := model.NewAutocompleteData("info", "[meeting-id]", "Show the live stream's watch link")
+	autocompleteMeetingArgument(info)
+	data.AddCommand(info)
+}
+
+func autocompleteList(data *model.AutocompleteData) {
+	data.AddStaticListArgument("Meetings to list", false, []model.AutocompleteListItem{
+		{Item: "--channel", HelpText: "Meetings of this channel"},
+		{Item: "--mine", HelpText: "Meetings you created"},
+		{Item: "--all", HelpText: "All meetings (system admins only)"},
+	})
+}
+
+// autocompleteMeetingArgument suggests the user's recent meetings
+func autocompleteMeetingArgument(data *model.AutocompleteData) {
+	data.AddDynamicListArgument("Meeting, by default your last one", AutocompleteMeetingsPath, false)
+}
+
+func autocompleteUser(data *model.AutocompleteData) {
+	export := model.NewAutocompleteData("export", "<@user>", "Show the data stored about a user with a link to download it")
+	export.AddTextArgument("User to export", "<@user>", "")
+	data.AddCommand(export)
+
+	purge := model.NewAutocompleteData("purge", "<@user>", "Delete the data stored about a user")
+	purge.AddTextArgument("User to purge", "<@user>", "")
+	data.AddCommand(purge)
+}
+
+func autocompleteAdmin(data *model.AutocompleteData) {
+	tokens := model.NewAutocompleteData("tokens", "list|revoke <@user>|revoke-all", "Manage users' Telemost tokens")
+
+	tokens.AddCommand(model.NewAutocompleteData("list", "", "List the users who have connected Telemost"))
+
+	revoke := model.NewAutocompleteData("revoke", "<@user>", "Disconnect a user and revoke their token")
+	revoke.AddTextArgument("User to disconnect", "<@user>", "")
+	tokens.AddCommand(revoke)
+
+	tokens.AddCommand(model.NewAutocompleteData("revoke-all", "", "Disconnect all users and revoke their tokens"))
+
+	data.AddCommand(tokens)
+}
+
+func autocompleteIncident(data *model.AutocompleteData) {
+	data.AddTextArgument("Incident title followed by the users and groups to invite", "<title> [@user|@group ...]", "")
+}
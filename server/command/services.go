@@ -0,0 +1,52 @@
+package command
+
+import (
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// MeetingService creates and manages meetings on behalf of users. It authorizes the user, picks
+// the meeting provider of the channel and authenticates as the user.
+type MeetingService interface {
+	CreateMeeting(req *MeetingRequest) (*TelemostMeeting, error)
+	GetMeeting(userID, channelID, meetingID string) (*TelemostMeeting, error)
+	UpdateLiveStream(req *LiveStreamRequest) (*TelemostMeeting, error)
+	EndMeeting(userID, channelID, meetingID string) error
+	GetLastMeeting(userID string) (*LastMeeting, error)
+	GetMeetingRecord(meetingID string) (*MeetingRecord, error)
+	ListMeetings(query *MeetingListQuery) (*MeetingList, error)
+	GetMeetingTemplates() ([]MeetingTemplateInfo, error)
+	ApplyMeetingTemplate(name string, req *MeetingRequest) error
+	GetIncidentChannelNameTemplate() string
+}
+
+// AuthService manages users' Telemost connections. Tokens are validated the same way for every
+// caller: expired tokens are refreshed when possible and removed otherwise. Disconnected tokens
+// are revoked with Yandex.
+type AuthService interface {
+	IsConnected(userID string) bool
+	CheckMeetingCreation(userID string, channel *model.Channel) error
+	GetConnectURL(channelID string) string
+	DisconnectUser(userID string) (*TokenRevocation, error)
+	GetUserStatus(userID string) (*UserStatus, error)
+}
+
+// AdminService provides the tools for system admins
+type AdminService interface {
+	RunDiagnostics() *Diagnostics
+	GetUserData(userID string) (*UserData, error)
+	PurgeUserData(adminID, userID string) (*UserData, *TokenRevocation, error)
+	ListConnectedUsers() ([]ConnectedUser, error)
+	RevokeUserToken(adminID, userID string) (*TokenRevocation, error)
+	RevokeAllUserTokens(adminID string) (map[string]*TokenRevocation, error)
+}
+
+// Services are the plugin services used by the command handler
+type Services struct {
+	Meetings MeetingService
+	Auth     AuthService
+	Admin    AdminService
+
+	// Audit is the plugin's audit store, shared so there is a single store over its keys
+	Audit *audit.Store
+}
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// startOptions are the flags accepted by /telemost start
+type startOptions struct {
+	// Template is the name of the meeting template to apply
+	Template string
+
+	// LiveStream is passed on as MeetingRequest.LiveStream, overriding the template's
+	LiveStream string
+
+	// Persistent exempts the meeting from the auto-end policy
+	Persistent bool
+}
+
+// handleStart creates a meeting with the channel's meeting provider and announces it in the
+// channel: /telemost start [--template <name>] [--stream public|org|off] [--persistent]
+func (h *Handler) handleStart(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	options, errResponse := parseStartOptions(params)
+	if errResponse != nil {
+		return errResponse, nil
+	}
+
+	// In direct and group messages the meeting works as an ad-hoc call
+	channel, err := h.client.Channel.Get(args.ChannelId)
+	if err == nil && (channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup) {
+		return h.startCall(args, channel, options)
+	}
+
+	req := &MeetingRequest{
+		UserID:    args.UserId,
+		ChannelID: args.ChannelId,
+		Title:     "Telemost Meeting",
+		Source:    "command",
+	}
+	if errResponse := h.applyStartOptions(req, options); errResponse != nil {
+		return errResponse, nil
+	}
+
+	// Create the meeting with the channel's meeting provider
+	meeting, err := h.meetings.CreateMeeting(req)
+	if err != nil {
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to create meeting!**\n\nError: %s\n\nPlease try again or contact support.", err.Error()),
+		}, nil
+	}
+
+	// Announce the meeting as the bot with props for the custom component to render
+	post := h.newMeetingPost(args.UserId, args.ChannelId, meeting, req.Title)
+	post.RootId = args.RootId
+	if err := h.client.Post.CreatePost(post); err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to post the meeting!**\n\nThe meeting was created, you can join it here: %s", meeting.JoinURL),
+		}, nil
+	}
+	return &model.CommandResponse{}, nil
+}
+
+// parseStartOptions parses /telemost start [--template <name>] [--stream public|org|off]
+// [--persistent], or returns the response explaining the usage
+func parseStartOptions(params []string) (*startOptions, *model.CommandResponse) {
+	options := &startOptions{}
+	for i := 0; i < len(params); i++ {
+		flag := strings.ToLower(params[i])
+		if flag == "--persistent" {
+			options.Persistent = true
+			continue
+		}
+		if flag != "--template" && flag != "--stream" {
+			return nil, startUsageResponse(fmt.Sprintf("Unknown option `%s`.", params[i]))
+		}
+		if i+1 >= len(params) {
+			return nil, startUsageResponse(fmt.Sprintf("`%s` needs a value.", flag))
+		}
+		i++
+
+		if flag == "--template" {
+			options.Template = params[i]
+			continue
+		}
+
+		if strings.EqualFold(params[i], LiveStreamOff) {
+			options.LiveStream = LiveStreamOff
+			continue
+		}
+		accessLevel, ok := parseLiveStreamAccessLevel(params[i])
+		if !ok {
+			return nil, startUsageResponse(fmt.Sprintf("Unknown live stream access `%s`.", params[i]))
+		}
+		options.LiveStream = accessLevel
+	}
+	return options, nil
+}
+
+func startUsageResponse(reason string) *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         fmt.Sprintf("**❌ Invalid command!** %s\n\nUsage: `/telemost start [--template <name>] [--stream public|org|off] [--persistent]`", reason),
+	}
+}
+
+// applyStartOptions merges the template and flags into a meeting request, or returns the response
+// explaining why the template can't be used
+func (h *Handler) applyStartOptions(req *MeetingRequest, options *startOptions) *model.CommandResponse {
+	if options.Template != "" {
+		if err := h.meetings.ApplyMeetingTemplate(options.Template, req); err != nil {
+			var notFoundErr *TemplateNotFoundError
+			if errors.As(err, &notFoundErr) {
+				return &model.CommandResponse{
+					ResponseType: model.CommandResponseTypeEphemeral,
+					Text:         fmt.Sprintf("**❌ Unknown template!** There is no meeting template named `%s`. Use `/telemost templates` to see the available templates.", notFoundErr.Name),
+				}
+			}
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("**❌ Failed to apply the template!**\n\nError: %s", err.Error()),
+			}
+		}
+	}
+
+	if options.LiveStream != "" {
+		req.LiveStream = options.LiveStream
+	}
+	if options.Persistent {
+		req.Persistent = true
+	}
+	return nil
+}
+
+// handleTemplates lists the meeting templates users can start with --template
+func (h *Handler) handleTemplates(_ *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	templates, err := h.meetings.GetMeetingTemplates()
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to load the meeting templates!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+	if len(templates) == 0 {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**No meeting templates.** A system admin can define them in the plugin settings.",
+		}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Meeting templates**\n\nStart a meeting from a template with `/telemost start --template <name>`.\n\n")
+	for _, template := range templates {
+		fmt.Fprintf(&sb, "- `%s`", template.Name)
+		if template.Title != "" {
+			fmt.Fprintf(&sb, " - %s", template.Title)
+		}
+		sb.WriteString("\n")
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         sb.String(),
+	}, nil
+}
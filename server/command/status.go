@@ -0,0 +1,116 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const statusTimeFormat = "2006-01-02 15:04 UTC"
+
+// LastMeeting is the latest meeting a user created
+type LastMeeting struct {
+	ID        string    `json:"id"`
+	JoinURL   string    `json:"join_url"`
+	Title     string    `json:"title"`
+	ChannelID string    `json:"channel_id"`
+	Provider  string    `json:"provider,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserStatus describes a user's Telemost connection and the defaults their meetings get
+type UserStatus struct {
+	Connected    bool
+	ConnectedAt  time.Time
+	ExpiresAt    time.Time
+	Login        string
+	Email        string
+	DisplayName  string
+	AccountError string
+	LastMeeting  *LastMeeting
+
+	WaitingRoomLevel      string
+	LiveStream            bool
+	LiveStreamAccessLevel string
+}
+
+// handleStatus shows the user's connection, account and meeting defaults
+func (h *Handler) handleStatus(args *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	status, err := h.auth.GetUserStatus(args.UserId)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to load your status!**\n\nError: %s", err.Error()),
+		}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Telemost status**\n\n")
+
+	if !status.Connected {
+		sb.WriteString("❌ Not connected. Use `/telemost connect` to authenticate.\n")
+	} else {
+		switch {
+		case status.Login != "":
+			fmt.Fprintf(&sb, "✅ Connected as **%s**", status.Login)
+			if status.Email != "" {
+				fmt.Fprintf(&sb, " (%s)", status.Email)
+			}
+			sb.WriteString("\n")
+		case status.AccountError != "":
+			fmt.Fprintf(&sb, "✅ Connected, but the Yandex account could not be loaded: %s\n", status.AccountError)
+		default:
+			sb.WriteString("✅ Connected\n")
+		}
+
+		if !status.ConnectedAt.IsZero() {
+			fmt.Fprintf(&sb, "- Connected: %s\n", status.ConnectedAt.UTC().Format(statusTimeFormat))
+		}
+		fmt.Fprintf(&sb, "- Token expires: %s (in %s)\n", status.ExpiresAt.UTC().Format(statusTimeFormat), formatRemaining(time.Until(status.ExpiresAt)))
+	}
+
+	if status.LastMeeting != nil {
+		title := status.LastMeeting.Title
+		if title == "" {
+			title = "Telemost Meeting"
+		}
+		fmt.Fprintf(&sb, "- Last meeting: [%s](%s)", title, status.LastMeeting.JoinURL)
+		if status.LastMeeting.ChannelID != "" {
+			if channel, err := h.client.Channel.Get(status.LastMeeting.ChannelID); err == nil && channel.Name != "" {
+				fmt.Fprintf(&sb, " in ~%s", channel.Name)
+			}
+		}
+		fmt.Fprintf(&sb, " on %s\n", status.LastMeeting.CreatedAt.UTC().Format(statusTimeFormat))
+	}
+
+	sb.WriteString("\n**Meeting defaults**\n")
+	waitingRoom := status.WaitingRoomLevel
+	if waitingRoom == "" {
+		waitingRoom = "PUBLIC"
+	}
+	fmt.Fprintf(&sb, "- Waiting room: %s\n", waitingRoom)
+	if status.LiveStream {
+		fmt.Fprintf(&sb, "- Live stream: enabled (%s)\n", status.LiveStreamAccessLevel)
+	} else {
+		sb.WriteString("- Live stream: disabled\n")
+	}
+
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         sb.String(),
+	}, nil
+}
+
+// formatRemaining renders a duration in hours or days, whichever reads better
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d minute(s)", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hour(s)", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d day(s)", int(d.Hours()/24))
+	}
+}
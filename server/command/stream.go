@@ -0,0 +1,123 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// parseLiveStreamAccessLevel maps public and org to the Telemost access levels
+func parseLiveStreamAccessLevel(value string) (string, bool) {
+	switch strings.ToLower(value) {
+	case "public":
+		return LiveStreamPublic, true
+	case "org", "organization":
+		return LiveStreamOrganization, true
+	}
+	return "", false
+}
+
+// handleStream starts, stops or shows the live stream of a meeting, by default the user's last one:
+// /telemost stream start [public|org] [meeting-id]
+// /telemost stream stop [meeting-id]
+// /telemost stream info [meeting-id]
+func (h *Handler) handleStream(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	if len(params) == 0 {
+		return streamUsageResponse(), nil
+	}
+
+	action := strings.ToLower(params[0])
+	params = params[1:]
+
+	accessLevel := ""
+	if action == "start" && len(params) > 0 {
+		if level, ok := parseLiveStreamAccessLevel(params[0]); ok {
+			accessLevel = level
+			params = params[1:]
+		}
+	}
+
+	if len(params) > 1 || (action != "start" && action != "stop" && action != "info") {
+		return streamUsageResponse(), nil
+	}
+
+	meetingID, errResponse := h.targetMeetingID(args.UserId, params)
+	if errResponse != nil {
+		return errResponse, nil
+	}
+
+	if action == "info" {
+		meeting, err := h.meetings.GetMeeting(args.UserId, args.ChannelId, meetingID)
+		if err != nil {
+			if response := meetingErrorResponse(err); response != nil {
+				return response, nil
+			}
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("**❌ Failed to load the meeting!**\n\nError: %s", err.Error()),
+			}, nil
+		}
+		if meeting.LiveStream == nil || meeting.LiveStream.WatchURL == "" {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("**📺 Live stream**\n\nMeeting `%s` isn't being streamed. Use `/telemost stream start` to start a stream.", meeting.ID),
+			}, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**📺 Live stream**\n\nMeeting `%s` is being streamed: [watch the live stream](%s)", meeting.ID, meeting.LiveStream.WatchURL),
+		}, nil
+	}
+
+	if action == "stop" {
+		accessLevel = LiveStreamOff
+	}
+
+	meeting, err := h.meetings.UpdateLiveStream(&LiveStreamRequest{
+		UserID:      args.UserId,
+		ChannelID:   args.ChannelId,
+		MeetingID:   meetingID,
+		AccessLevel: accessLevel,
+	})
+	if err != nil {
+		var permissionErr *PermissionError
+		if errors.As(err, &permissionErr) {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("**🚫 Not allowed!**\n\nYou cannot start a live stream: %s.", permissionErr.Reason),
+			}, nil
+		}
+		if response := meetingErrorResponse(err); response != nil {
+			return response, nil
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to %s the live stream!**\n\nError: %s", action, err.Error()),
+		}, nil
+	}
+
+	if action == "stop" {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**✅ Live stream stopped**\n\nMeeting `%s` is no longer streamed.", meeting.ID),
+		}, nil
+	}
+
+	text := fmt.Sprintf("**✅ Live stream started**\n\nMeeting `%s` is now streamed.", meeting.ID)
+	if meeting.LiveStream != nil && meeting.LiveStream.WatchURL != "" {
+		text += fmt.Sprintf(" [Watch the live stream](%s)", meeting.LiveStream.WatchURL)
+	}
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         text,
+	}, nil
+}
+
+func streamUsageResponse() *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         "**Usage:**\n- `/telemost stream start [public|org] [meeting-id]` - Start a live stream\n- `/telemost stream stop [meeting-id]` - Stop a live stream\n- `/telemost stream info [meeting-id]` - Show the live stream's watch link\n\nWithout a meeting ID, your last meeting is used.",
+	}
+}
@@ -1,156 +1,151 @@
 package command
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/metrics"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
 // Handler handles slash commands
 type Handler struct {
-	client *pluginapi.Client
-	plugin interface {
-		CreateMeetingWithUserToken(token, title, description string) (*TelemostMeeting, error)
-	}
+	client    *pluginapi.Client
+	audit     *audit.Store
+	metrics   *metrics.Metrics
+	meetings  MeetingService
+	auth      AuthService
+	admin     AdminService
+	botUserID string
+	router    *router
 }
 
 // NewCommandHandler creates a new command handler
-func NewCommandHandler(client *pluginapi.Client, plugin interface {
-	CreateMeetingWithUserToken(token, title, description string) (*TelemostMeeting, error)
-}) *Handler {
+func NewCommandHandler(client *pluginapi.Client, services Services, botUserID string, metrics *metrics.Metrics) *Handler {
 	return &Handler{
-		client: client,
-		plugin: plugin,
+		client:    client,
+		audit:     services.Audit,
+		metrics:   metrics,
+		meetings:  services.Meetings,
+		auth:      services.Auth,
+		admin:     services.Admin,
+		botUserID: botUserID,
+		router:    newRouter(),
 	}
 }
 
 // Handle handles slash command execution
 func (h *Handler) Handle(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
-	// Parse command
+	// Parse command, showing the help if no subcommand is provided
 	fields := strings.Fields(args.Command)
+	name := "help"
+	params := []string{}
+	if len(fields) >= 2 {
+		name = fields[1]
+		params = fields[2:]
+	}
 
-	// If no subcommand provided, show help
-	if len(fields) < 2 {
+	// Unknown subcommands are counted together to keep the number of metric series bounded
+	cmd := h.router.get(name)
+	if cmd == nil {
+		h.metrics.IncCommand("unknown")
 		return &model.CommandResponse{
 			ResponseType: model.CommandResponseTypeEphemeral,
-			Text:         "**Available commands:**\n- `/telemost start` - Start a new meeting (requires authentication)\n- `/telemost connect` - Authenticate with Telemost OAuth\n- `/telemost disconnect` - Remove Telemost authentication\n- `/telemost help` - Show this help message",
+			Text:         fmt.Sprintf("Unknown command: `%s`. Use `/telemost help` to see available commands.", strings.ToLower(name)),
 		}, nil
 	}
+	h.metrics.IncCommand(cmd.Name)
 
-	subcommand := strings.ToLower(fields[1])
-
-	switch subcommand {
-	case "start":
-		// Check if user has OAuth token (production logic)
-		var userToken []byte
-		err := h.client.KV.Get(fmt.Sprintf("telemost_user_token_%s", args.UserId), &userToken)
-		if err != nil || userToken == nil {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**Telemost not authenticated!**\n\nPlease authenticate with Telemost first:\n1. Use `/telemost connect` to start OAuth authentication\n2. Complete the OAuth flow in your browser\n3. Try `/telemost start` again",
-			}, nil
-		}
-
-		// Parse user token
-		var tokenData map[string]interface{}
-		if err := json.Unmarshal(userToken, &tokenData); err != nil {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**Authentication error!** Please reconnect with `/telemost connect`.",
-			}, nil
-		}
-
-		accessToken, ok := tokenData["access_token"].(string)
-		if !ok || accessToken == "" {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**Invalid token!** Please reconnect with `/telemost connect`.",
-			}, nil
-		}
-
-		// Create real Telemost meeting using production API
-		meeting, err := h.plugin.CreateMeetingWithUserToken(accessToken, "Telemost Meeting", "")
-		if err != nil {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         fmt.Sprintf("**❌ Failed to create meeting!**\n\nError: %s\n\nPlease try again or contact support.", err.Error()),
-			}, nil
-		}
-
-		// Create custom post with props for the custom component to render
-		response := &model.CommandResponse{
-			ResponseType: model.CommandResponseTypeInChannel,
-			Text:         "", // Empty text since we render everything custom
-			Props: map[string]interface{}{
-				"type":      "custom_telemost_meeting",
-				"joinURL":   meeting.JoinURL,
-				"meetingID": meeting.ID,
-				"title":     "Telemost Meeting",
-			},
-		}
-		return response, nil
-
-	case "connect":
-		// Check if user is already authenticated
-		var userToken []byte
-		err := h.client.KV.Get(fmt.Sprintf("telemost_user_token_%s", args.UserId), &userToken)
-		if err == nil && userToken != nil {
-			// User is already authenticated
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**✅ Already Connected to Telemost**\n\nYou are already authenticated with Telemost. You can:\n- Use `/telemost start` to create a meeting\n- Use `/telemost disconnect` to remove authentication",
-			}, nil
-		}
-
-		// Start OAuth authentication flow (production logic)
-		// Get the site URL from configuration to build the full OAuth URL
-		// For now, use a relative path that will be resolved by the browser
-		oauthURL := fmt.Sprintf("/plugins/com.mattermost.plugin-telemost/oauth/start?channel_id=%s", args.ChannelId)
-
+	if cmd.AdminOnly && !h.client.User.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
 		return &model.CommandResponse{
 			ResponseType: model.CommandResponseTypeEphemeral,
-			Text:         fmt.Sprintf("**🔗 Telemost Authentication Required**\n\nTo connect to Telemost, please complete the OAuth authentication:\n\n[**Click here to authenticate with Telemost**](%s)\n\nAfter authentication, you'll be able to create meetings using `/telemost start`.", oauthURL),
+			Text:         fmt.Sprintf("**❌ Permission denied!** Only system administrators can use `/telemost %s`.", cmd.Name),
 		}, nil
+	}
 
-	case "disconnect":
-		// Check if user is authenticated before trying to disconnect
-		var userToken []byte
-		err := h.client.KV.Get(fmt.Sprintf("telemost_user_token_%s", args.UserId), &userToken)
-		if err != nil || userToken == nil {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**Not authenticated!** You are not currently authenticated with Telemost. Use `/telemost connect` to authenticate first.",
-			}, nil
-		}
-
-		// Remove OAuth token (production logic)
-		tokenKey := fmt.Sprintf("telemost_user_token_%s", args.UserId)
-		err = h.client.KV.Delete(tokenKey)
-		if err != nil {
-			return &model.CommandResponse{
-				ResponseType: model.CommandResponseTypeEphemeral,
-				Text:         "**❌ Failed to disconnect!** There was an error removing your authentication. Please try again.",
-			}, nil
-		}
+	return cmd.Execute(h, args, params)
+}
 
-		return &model.CommandResponse{
-			ResponseType: model.CommandResponseTypeEphemeral,
-			Text:         "**✅ Disconnected from Telemost**\n\nYour Telemost authentication has been removed. Use `/telemost connect` to authenticate again.",
-		}, nil
+// handleHelp lists the subcommands the user can run
+func (h *Handler) handleHelp(args *model.CommandArgs, _ []string) (*model.CommandResponse, *model.AppError) {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         h.router.helpText(h.client.User.HasPermissionTo(args.UserId, model.PermissionManageSystem)),
+	}, nil
+}
 
-	case "help":
-		return &model.CommandResponse{
-			ResponseType: model.CommandResponseTypeEphemeral,
-			Text:         "**Available commands:**\n- `/telemost start` - Start a new meeting (requires authentication)\n- `/telemost connect` - Authenticate with Telemost OAuth\n- `/telemost disconnect` - Remove Telemost authentication\n- `/telemost help` - Show this help message",
-		}, nil
+// newMeetingPost builds the bot's announcement of a meeting a user created
+func (h *Handler) newMeetingPost(creatorID, channelID string, meeting *TelemostMeeting, title string) *model.Post {
+	pretext := "A meeting has been started"
+	if creator, err := h.client.User.Get(creatorID); err == nil {
+		pretext = fmt.Sprintf("@%s has started a meeting", creator.Username)
+	}
+	return NewMeetingPost(h.botUserID, creatorID, channelID, meeting, title, pretext)
+}
 
-	default:
-		return &model.CommandResponse{
-			ResponseType: model.CommandResponseTypeEphemeral,
-			Text:         fmt.Sprintf("Unknown command: `%s`. Use `/telemost help` to see available commands.", subcommand),
-		}, nil
+// NewMeetingPost builds the bot's meeting card, rendered by the webapp's meeting component. Every
+// entry point announces meetings with it. creatorID is empty for meetings no user created.
+func NewMeetingPost(botUserID, creatorID, channelID string, meeting *TelemostMeeting, title, pretext string) *model.Post {
+	post := &model.Post{
+		UserId:    botUserID,
+		ChannelId: channelID,
+		Type:      MeetingPostType,
+	}
+	post.SetProps(model.StringInterface{
+		"joinURL":   meeting.JoinURL,
+		"meetingID": meeting.ID,
+		"title":     title,
+		"pretext":   pretext,
+	})
+	if creatorID != "" {
+		post.AddProp("creatorId", creatorID)
+	}
+	return post
+}
+
+// meetingErrorResponse responds with MeetingErrorMessage, or returns nil for any other error
+func meetingErrorResponse(err error) *model.CommandResponse {
+	message := MeetingErrorMessage(err)
+	if message == "" {
+		return nil
+	}
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         message,
+	}
+}
+
+// MeetingErrorMessage explains a missing connection, an unsupported feature, a rate limit or a
+// permission rejection to the user, or returns "" for any other error. Every entry point shows
+// these errors with it, so they read the same everywhere.
+func MeetingErrorMessage(err error) string {
+	var notConnectedErr *NotConnectedError
+	if errors.As(err, &notConnectedErr) {
+		return "**Telemost not authenticated!**\n\nPlease authenticate with Telemost first:\n1. Use `/telemost connect` to start OAuth authentication\n2. Complete the OAuth flow in your browser\n3. Try your command again"
+	}
+
+	var unsupportedErr *UnsupportedError
+	if errors.As(err, &unsupportedErr) {
+		return fmt.Sprintf("**❌ Not supported!**\n\nMeetings here are hosted on %s, which doesn't support %s.", unsupportedErr.Provider, unsupportedErr.Feature)
+	}
+
+	var permissionErr *PermissionError
+	if errors.As(err, &permissionErr) {
+		return fmt.Sprintf("**🚫 Not allowed!**\n\nYou cannot create a meeting here: %s.", permissionErr.Reason)
+	}
+
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		return ""
+	}
+
+	subject := "You have"
+	if rateErr.Scope == "channel" {
+		subject = "This channel has"
 	}
+	return fmt.Sprintf("**⏳ Too many meetings!**\n\n%s reached the limit of %d meetings per hour. Please try again in %d minute(s).", subject, rateErr.Limit, int(math.Ceil(rateErr.RetryAfter.Minutes())))
 }
@@ -0,0 +1,209 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMeetings is a MeetingService creating meetings with a fixed result. Methods the tests
+// don't use panic through the nil embedded interface.
+type fakeMeetings struct {
+	MeetingService
+
+	meeting  *TelemostMeeting
+	err      error
+	requests []*MeetingRequest
+}
+
+func (f *fakeMeetings) CreateMeeting(req *MeetingRequest) (*TelemostMeeting, error) {
+	f.requests = append(f.requests, req)
+	return f.meeting, f.err
+}
+
+// fakeAuth is an AuthService with a fixed connection state. A user whose token expired and
+// couldn't be refreshed is not connected.
+type fakeAuth struct {
+	AuthService
+
+	connected  bool
+	revocation *TokenRevocation
+	err        error
+}
+
+func (f *fakeAuth) IsConnected(string) bool { return f.connected }
+
+func (f *fakeAuth) GetConnectURL(channelID string) string {
+	return "https://mattermost.example.com/plugins/telemost/oauth/connect?channel_id=" + channelID
+}
+
+func (f *fakeAuth) DisconnectUser(string) (*TokenRevocation, error) { return f.revocation, f.err }
+
+// fakeAdmin is an AdminService listing a fixed set of connected users
+type fakeAdmin struct {
+	AdminService
+
+	users []ConnectedUser
+}
+
+func (f *fakeAdmin) ListConnectedUsers() ([]ConnectedUser, error) { return f.users, nil }
+
+func newTestHandler(api *plugintest.API, services Services) *Handler {
+	return NewCommandHandler(pluginapi.NewClient(api, nil), services, "botid", nil)
+}
+
+func commandArgs(command string) *model.CommandArgs {
+	return &model.CommandArgs{Command: command, UserId: "userid", ChannelId: "channelid"}
+}
+
+func TestHandleStart(t *testing.T) {
+	for name, tc := range map[string]struct {
+		meeting      *TelemostMeeting
+		err          error
+		expectPost   bool
+		expectedText string
+	}{
+		"creates and announces the meeting": {
+			meeting:    &TelemostMeeting{ID: "meetingid", JoinURL: "https://telemost.yandex.ru/j/123"},
+			expectPost: true,
+		},
+		"not connected": {
+			err:          &NotConnectedError{},
+			expectedText: "**Telemost not authenticated!**",
+		},
+		"expired token": {
+			err:          fmt.Errorf("the token expired and could not be refreshed: %w", &NotConnectedError{}),
+			expectedText: "Use `/telemost connect` to start OAuth authentication",
+		},
+		"rate limited user": {
+			err:          &RateLimitError{Scope: "user", Limit: 10, RetryAfter: 90 * time.Second},
+			expectedText: "You have reached the limit of 10 meetings per hour. Please try again in 2 minute(s).",
+		},
+		"rate limited channel": {
+			err:          &RateLimitError{Scope: "channel", Limit: 30, RetryAfter: 5 * time.Minute},
+			expectedText: "This channel has reached the limit of 30 meetings per hour. Please try again in 5 minute(s).",
+		},
+		"permission denied": {
+			err:          &PermissionError{Reason: "only channel admins can create meetings"},
+			expectedText: "You cannot create a meeting here: only channel admins can create meetings.",
+		},
+		"unsupported": {
+			err:          &UnsupportedError{Provider: "Jitsi", Feature: "live streams"},
+			expectedText: "Meetings here are hosted on Jitsi, which doesn't support live streams.",
+		},
+		"provider failure": {
+			err:          errors.New("telemost is down"),
+			expectedText: "**❌ Failed to create meeting!**\n\nError: telemost is down",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			api.On("GetChannel", "channelid").Return(&model.Channel{Id: "channelid", Type: model.ChannelTypeOpen}, nil)
+			if tc.expectPost {
+				api.On("GetUser", "userid").Return(&model.User{Id: "userid", Username: "alice"}, nil)
+				api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+					return post.UserId == "botid" && post.Type == MeetingPostType &&
+						post.GetProp("joinURL") == tc.meeting.JoinURL &&
+						post.GetProp("pretext") == "@alice has started a meeting"
+				})).Return(&model.Post{}, nil)
+			}
+
+			meetings := &fakeMeetings{meeting: tc.meeting, err: tc.err}
+			h := newTestHandler(api, Services{Meetings: meetings, Auth: &fakeAuth{}})
+
+			response, appErr := h.Handle(commandArgs("/telemost start"))
+			require.Nil(t, appErr)
+			require.NotNil(t, response)
+			assert.Contains(t, response.Text, tc.expectedText)
+
+			require.Len(t, meetings.requests, 1)
+			assert.Equal(t, "userid", meetings.requests[0].UserID)
+			assert.Equal(t, "channelid", meetings.requests[0].ChannelID)
+			assert.Equal(t, "command", meetings.requests[0].Source)
+		})
+	}
+}
+
+func TestHandleConnect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		command      string
+		auth         *fakeAuth
+		expectedText string
+	}{
+		"connect when connected": {
+			command:      "/telemost connect",
+			auth:         &fakeAuth{connected: true},
+			expectedText: "**✅ Already Connected to Telemost**",
+		},
+		"connect when not connected": {
+			command:      "/telemost connect",
+			auth:         &fakeAuth{},
+			expectedText: "(https://mattermost.example.com/plugins/telemost/oauth/connect?channel_id=channelid)",
+		},
+		"disconnect when not connected": {
+			command:      "/telemost disconnect",
+			auth:         &fakeAuth{},
+			expectedText: "**Not authenticated!**",
+		},
+		"disconnect revokes the token": {
+			command:      "/telemost disconnect",
+			auth:         &fakeAuth{connected: true, revocation: &TokenRevocation{Status: TokenRevoked}},
+			expectedText: "Your Telemost authentication has been removed. The token was revoked with Yandex.",
+		},
+		"disconnect queues the revocation": {
+			command:      "/telemost disconnect",
+			auth:         &fakeAuth{connected: true, revocation: &TokenRevocation{Status: TokenRevocationQueued, Error: "timeout"}},
+			expectedText: "Yandex couldn't be reached to revoke the token (timeout)",
+		},
+		"disconnect fails": {
+			command:      "/telemost disconnect",
+			auth:         &fakeAuth{connected: true, err: errors.New("kv error")},
+			expectedText: "**❌ Failed to disconnect!**",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			h := newTestHandler(api, Services{Meetings: &fakeMeetings{}, Auth: tc.auth})
+
+			response, appErr := h.Handle(commandArgs(tc.command))
+			require.Nil(t, appErr)
+			assert.Contains(t, response.Text, tc.expectedText)
+		})
+	}
+}
+
+func TestHandleAdminOnly(t *testing.T) {
+	for name, tc := range map[string]struct {
+		isAdmin      bool
+		expectedText string
+	}{
+		"denied for users": {
+			expectedText: "**❌ Permission denied!** Only system administrators can use `/telemost admin`.",
+		},
+		"allowed for system admins": {
+			isAdmin:      true,
+			expectedText: "No users have connected Telemost.",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			api.On("HasPermissionTo", "userid", model.PermissionManageSystem).Return(tc.isAdmin)
+			h := newTestHandler(api, Services{Meetings: &fakeMeetings{}, Auth: &fakeAuth{}, Admin: &fakeAdmin{}})
+
+			response, appErr := h.Handle(commandArgs("/telemost admin tokens list"))
+			require.Nil(t, appErr)
+			assert.Equal(t, tc.expectedText, response.Text)
+		})
+	}
+}
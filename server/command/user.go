@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const userExportPluginPath = "/plugins/com.mattermost.plugin-telemost/api/v1/users/export"
+
+// UserData is everything the plugin stores about a user
+type UserData struct {
+	UserID          string           `json:"user_id"`
+	Connection      *UserConnection  `json:"connection,omitempty"`
+	OAuthStates     []UserOAuthState `json:"pending_oauth_states"`
+	LastMeeting     *LastMeeting     `json:"last_meeting,omitempty"`
+	Meetings        []MeetingRecord  `json:"meetings"`
+	MeetingsCreated int64            `json:"meetings_created"`
+	AuditEvents     []*audit.Event   `json:"audit_events"`
+
+	TokenRevocations  []QueuedTokenRevocation `json:"queued_token_revocations"`
+	WebhookDeliveries []QueuedWebhookDelivery `json:"queued_webhook_deliveries"`
+}
+
+// QueuedTokenRevocation is a revocation of one of the user's removed tokens waiting for a retry,
+// described without the token
+type QueuedTokenRevocation struct {
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// QueuedWebhookDelivery is an event about the user waiting for a retry to an outgoing webhook
+type QueuedWebhookDelivery struct {
+	EventType     string    `json:"event_type"`
+	URL           string    `json:"url"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// UserConnection describes a user's Telemost connection without its tokens
+type UserConnection struct {
+	ConnectedAt time.Time `json:"connected_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Refreshable bool      `json:"refreshable"`
+}
+
+// UserOAuthState is an OAuth flow the user started but hasn't completed
+type UserOAuthState struct {
+	ChannelID string    `json:"channel_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleUser exports or purges the data the plugin stores about a user for system admins:
+// /telemost user export|purge <user>
+func (h *Handler) handleUser(args *model.CommandArgs, params []string) (*model.CommandResponse, *model.AppError) {
+	if len(params) != 2 || (params[0] != "export" && params[0] != "purge") {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "**Usage:** `/telemost user export|purge <@user>`\n\n`export` shows what the plugin stores about the user with a link to download it, `purge` deletes it.",
+		}, nil
+	}
+
+	user, err := h.lookupUser(params[1])
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("Unknown user: `%s`.", params[1]),
+		}, nil
+	}
+
+	if params[0] == "purge" {
+		data, revocation, err := h.admin.PurgeUserData(args.UserId, user.Id)
+		if err != nil {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeEphemeral,
+				Text:         fmt.Sprintf("**❌ Failed to purge the data of @%s!**\n\nError: %s", user.Username, err.Error()),
+			}, nil
+		}
+		text := fmt.Sprintf("**✅ Purged the Telemost data of @%s**\n\n%s", user.Username, userDataSummary(data))
+		if revocation != nil {
+			text += "\n\n" + strings.TrimSpace(tokenRevocationText(revocation))
+		}
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         text,
+		}, nil
+	}
+
+	data, err := h.admin.GetUserData(user.Id)
+	if err != nil {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         fmt.Sprintf("**❌ Failed to load the data of @%s!**\n\nError: %s", user.Username, err.Error()),
+		}, nil
+	}
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text: fmt.Sprintf("**Telemost data of @%s**\n\n%s\n\n[Download as JSON](%s?user_id=%s)",
+			user.Username, userDataSummary(data), userExportPluginPath, user.Id),
+	}, nil
+}
+
+// lookupUser finds a user by @username or ID
+func (h *Handler) lookupUser(name string) (*model.User, error) {
+	user, err := h.client.User.GetByUsername(strings.TrimPrefix(name, "@"))
+	if err != nil && model.IsValidId(name) {
+		return h.client.User.Get(name)
+	}
+	return user, err
+}
+
+// userDataSummary lists how much data of each kind the plugin stores about a user
+func userDataSummary(data *UserData) string {
+	connection := "not connected"
+	if data.Connection != nil {
+		connection = fmt.Sprintf("connected since %s", data.Connection.ConnectedAt.UTC().Format(statusTimeFormat))
+	}
+	lastMeeting := "none"
+	if data.LastMeeting != nil {
+		lastMeeting = data.LastMeeting.ID
+	}
+
+	lines := []string{
+		"- Telemost connection: " + connection,
+		fmt.Sprintf("- Pending OAuth flows: %d", len(data.OAuthStates)),
+		"- Last meeting: " + lastMeeting,
+		fmt.Sprintf("- Meeting records: %d", len(data.Meetings)),
+		fmt.Sprintf("- Meetings created (statistics): %d", data.MeetingsCreated),
+		fmt.Sprintf("- Audit events: %d", len(data.AuditEvents)),
+		fmt.Sprintf("- Queued token revocations: %d", len(data.TokenRevocations)),
+		fmt.Sprintf("- Queued webhook deliveries: %d", len(data.WebhookDeliveries)),
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
 	"reflect"
+	"strings"
 
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pkg/errors"
 )
 
@@ -20,10 +27,32 @@ import (
 type configuration struct {
 	TelemostOAuthToken           string
 	YandexClientID               string
+	YandexClientSecret           string
 	SiteURL                      string
 	DefaultWaitingRoomLevel      string
 	EnableLiveStream             bool
 	DefaultLiveStreamAccessLevel string
+	LiveStreamBroadcastChannel   string
+	MeetingRateLimitPerUser      int
+	MeetingRateLimitPerChannel   int
+	AuditRetentionDays           int
+	AutoEndMeetingsHours         int
+	TokenExpiryReminderHours     int
+	AllowedTeams                 string
+	AllowedChannels              string
+	AllowedRoles                 string
+	AllowedGroups                string
+	BlockPublicChannels          bool
+	BlockSharedChannels          bool
+	RestrictLiveStreamToAdmins   bool
+	IncomingWebhooks             string
+	OutgoingWebhookURLs          string
+	OutgoingWebhookSecret        string
+	IncidentChannelNameTemplate  string
+	MeetingTemplates             string
+	JitsiURL                     string
+	JitsiTeams                   string
+	JitsiChannels                string
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -33,13 +62,113 @@ func (c *configuration) Clone() *configuration {
 	return &clone
 }
 
-// IsValid checks if all the required fields are set.
+// waitingRoomLevels and liveStreamAccessLevels are the values the Telemost API accepts
+var (
+	waitingRoomLevels      = []string{"PUBLIC", "ORGANIZATION", "ADMINS"}
+	liveStreamAccessLevels = []string{"PUBLIC", "ORGANIZATION"}
+)
+
+// IsValid checks if all the required fields are set and well formed.
 func (c *configuration) IsValid() error {
 	if c.YandexClientID == "" {
 		return errors.New("must have a Yandex Client ID")
 	}
-	if c.SiteURL == "" {
-		return errors.New("must have a Site URL")
+	return c.validate()
+}
+
+// validate checks the format of the fields that are set. Unlike IsValid it accepts a
+// configuration that is incomplete, so admins can save settings one at a time.
+func (c *configuration) validate() error {
+	problems := []string{}
+
+	if c.SiteURL != "" {
+		if err := validateHTTPURL(c.SiteURL); err != nil {
+			problems = append(problems, fmt.Sprintf("Mattermost Site URL %s", err.Error()))
+		}
+	}
+	if c.DefaultWaitingRoomLevel != "" && !containsString(waitingRoomLevels, c.DefaultWaitingRoomLevel) {
+		problems = append(problems, fmt.Sprintf("Default Waiting Room Level must be one of %s, got %q", strings.Join(waitingRoomLevels, ", "), c.DefaultWaitingRoomLevel))
+	}
+	if c.DefaultLiveStreamAccessLevel != "" && !containsString(liveStreamAccessLevels, c.DefaultLiveStreamAccessLevel) {
+		problems = append(problems, fmt.Sprintf("Default Live Stream Access Level must be one of %s, got %q", strings.Join(liveStreamAccessLevels, ", "), c.DefaultLiveStreamAccessLevel))
+	}
+	if c.MeetingRateLimitPerUser < 0 {
+		problems = append(problems, "Meetings Per User Per Hour must not be negative")
+	}
+	if c.MeetingRateLimitPerChannel < 0 {
+		problems = append(problems, "Meetings Per Channel Per Hour must not be negative")
+	}
+	if c.AuditRetentionDays < 0 {
+		problems = append(problems, "Audit Log Retention (Days) must not be negative")
+	}
+	if c.AutoEndMeetingsHours < 0 {
+		problems = append(problems, "Auto-End Meetings After (Hours) must not be negative")
+	}
+	if c.TokenExpiryReminderHours < 0 {
+		problems = append(problems, "Token Expiry Reminder (Hours) must not be negative")
+	}
+	if c.JitsiURL != "" {
+		if err := validateHTTPURL(c.JitsiURL); err != nil {
+			problems = append(problems, fmt.Sprintf("Jitsi Server URL %s", err.Error()))
+		}
+	} else if c.JitsiTeams != "" || c.JitsiChannels != "" {
+		problems = append(problems, "Jitsi Teams and Jitsi Channels require a Jitsi Server URL")
+	}
+	for _, webhookURL := range parseWebhookURLs(c.OutgoingWebhookURLs) {
+		if err := validateHTTPURL(webhookURL); err != nil {
+			problems = append(problems, fmt.Sprintf("Outgoing webhook URL %q %s", webhookURL, err.Error()))
+		}
+	}
+	problems = append(problems, c.validateIncomingWebhooks()...)
+	problems = append(problems, c.validateMeetingTemplates()...)
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateIncomingWebhooks checks that every integration is parseable, named uniquely and signed
+func (c *configuration) validateIncomingWebhooks() []string {
+	if strings.TrimSpace(c.IncomingWebhooks) == "" {
+		return nil
+	}
+
+	var integrations []incomingWebhook
+	if err := json.Unmarshal([]byte(c.IncomingWebhooks), &integrations); err != nil {
+		return []string{fmt.Sprintf("Incoming Webhook Integrations must be a JSON array: %s", err.Error())}
+	}
+
+	problems := []string{}
+	seen := map[string]bool{}
+	for i, integration := range integrations {
+		switch {
+		case integration.Name == "":
+			problems = append(problems, fmt.Sprintf("incoming webhook #%d has no name", i+1))
+		case seen[integration.Name]:
+			problems = append(problems, fmt.Sprintf("incoming webhook %q is defined more than once", integration.Name))
+		case integration.Secret == "":
+			problems = append(problems, fmt.Sprintf("incoming webhook %q has no secret", integration.Name))
+		}
+		seen[integration.Name] = true
+	}
+	return problems
+}
+
+// validateHTTPURL checks that value is an absolute http or https URL without query or fragment
+func validateHTTPURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return errors.New("is not a valid URL")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("must start with http:// or https://")
+	}
+	if parsed.Host == "" {
+		return errors.New("must include a host name")
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return errors.New("must not contain a query or fragment")
 	}
 	return nil
 }
@@ -59,6 +188,50 @@ func (c *configuration) GetDefaultLiveStreamAccessLevel() string {
 	return c.DefaultLiveStreamAccessLevel
 }
 
+// GetIncidentChannelNameTemplate returns the template for incident channel names
+func (c *configuration) GetIncidentChannelNameTemplate() string {
+	if c.IncidentChannelNameTemplate == "" {
+		return command.DefaultIncidentChannelNameTemplate
+	}
+	return c.IncidentChannelNameTemplate
+}
+
+// getIncomingWebhook returns the incoming webhook integration with the given name, or nil
+func (c *configuration) getIncomingWebhook(name string) (*incomingWebhook, error) {
+	if strings.TrimSpace(c.IncomingWebhooks) == "" {
+		return nil, nil
+	}
+
+	var integrations []incomingWebhook
+	if err := json.Unmarshal([]byte(c.IncomingWebhooks), &integrations); err != nil {
+		return nil, errors.Wrap(err, "failed to parse incoming webhooks")
+	}
+
+	for i := range integrations {
+		if integrations[i].Name == name {
+			return &integrations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// getSiteURL returns the URL the plugin is reached at: the configured Site URL if set, otherwise
+// the server's Site URL
+func (p *Plugin) getSiteURL() string {
+	if siteURL := p.getConfiguration().SiteURL; siteURL != "" {
+		return strings.TrimRight(siteURL, "/")
+	}
+	if siteURL := p.API.GetConfig().ServiceSettings.SiteURL; siteURL != nil {
+		return strings.TrimRight(*siteURL, "/")
+	}
+	return ""
+}
+
+// connectURL returns the link starting the OAuth flow for a user in a channel
+func (p *Plugin) connectURL(channelID string) string {
+	return fmt.Sprintf("%s%s?channel_id=%s", p.getSiteURL(), oauthStartPath, url.QueryEscape(channelID))
+}
+
 // getConfiguration retrieves the active configuration under lock, making it safe to use
 // concurrently. The active configuration may change underneath the client of this method, but
 // the struct returned by this API call is considered immutable.
@@ -109,14 +282,63 @@ func (p *Plugin) OnConfigurationChange() error {
 		return errors.Wrap(err, "failed to load plugin configuration")
 	}
 
+	// Keep the previous configuration rather than running with malformed values
+	if err := configuration.validate(); err != nil {
+		return errors.Wrap(err, "invalid plugin configuration")
+	}
+
+	previous := p.getConfiguration()
 	p.setConfiguration(configuration)
 
+	if changed := changedConfigurationFields(previous, configuration); len(changed) > 0 {
+		p.recordAudit(audit.EventConfigChange, "", "", true, map[string]string{"fields": strings.Join(changed, ",")})
+	}
+
 	// Reinitialize Telemost client with new configuration
 	if configuration.TelemostOAuthToken != "" {
-		p.telemostClient = NewTelemostClient(configuration.TelemostOAuthToken, p.API)
+		p.telemostClient = NewTelemostClient(configuration.TelemostOAuthToken, p.API, p.metrics)
 	} else {
 		p.telemostClient = nil
 	}
 
 	return nil
 }
+
+// ConfigurationWillBeSaved rejects saving malformed plugin settings, showing the admin what is
+// wrong in the System Console
+func (p *Plugin) ConfigurationWillBeSaved(newCfg *model.Config) (*model.Config, error) {
+	settings, ok := newCfg.PluginSettings.Plugins[manifest.Id]
+	if !ok {
+		return nil, nil
+	}
+
+	// Plugin settings are stored with lowercased keys; encoding/json matches them to the fields
+	// case-insensitively, the same way LoadPluginConfiguration does
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal plugin settings")
+	}
+	var configuration configuration
+	if err := json.Unmarshal(settingsJSON, &configuration); err != nil {
+		return nil, errors.Wrap(err, "invalid plugin settings")
+	}
+
+	if err := configuration.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid Telemost plugin settings")
+	}
+	return nil, nil
+}
+
+// changedConfigurationFields returns the names, never the values, of the fields that differ
+func changedConfigurationFields(previous, current *configuration) []string {
+	previousValue := reflect.ValueOf(*previous)
+	currentValue := reflect.ValueOf(*current)
+
+	changed := []string{}
+	for i := 0; i < currentValue.NumField(); i++ {
+		if !reflect.DeepEqual(previousValue.Field(i).Interface(), currentValue.Field(i).Interface()) {
+			changed = append(changed, currentValue.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
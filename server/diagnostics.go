@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+const (
+	jobStatusKey            = "telemost_job_status"
+	jobOverdueAfter         = 2 * time.Hour
+	diagnosticsProbeTimeout = 5 * time.Second
+)
+
+// jobStatus is stored by the background job after every run so any node can report it
+type jobStatus struct {
+	LastRunAt  time.Time `json:"last_run_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// recordJobStatus stores when the background job last ran and how long it took
+func (p *Plugin) recordJobStatus(started time.Time) {
+	status := jobStatus{
+		LastRunAt:  started,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if _, err := p.client.KV.Set(jobStatusKey, status); err != nil {
+		p.API.LogError("Failed to store background job status", "error", err.Error())
+	}
+}
+
+// RunDiagnostics checks the configuration, connectivity and stored data
+func (s *adminService) RunDiagnostics() *command.Diagnostics {
+	config := s.p.getConfiguration()
+
+	diagnostics := &command.Diagnostics{
+		Checks: []command.DiagnosticCheck{
+			s.p.checkConfiguration(config),
+			s.p.checkSiteURL(config),
+			checkReachable("Telemost API", telemostAPIBaseURL),
+			checkReachable("Yandex OAuth", yandexOAuthURL),
+			s.p.checkServiceAccountToken(config),
+			s.p.checkBackgroundJob(),
+		},
+	}
+
+	records, err := s.p.countRecords()
+	if err != nil {
+		diagnostics.Checks = append(diagnostics.Checks, command.DiagnosticCheck{
+			Name:    "KV store",
+			Status:  command.DiagnosticError,
+			Details: err.Error(),
+		})
+	}
+	diagnostics.Records = records
+
+	return diagnostics
+}
+
+func (p *Plugin) checkConfiguration(config *configuration) command.DiagnosticCheck {
+	check := command.DiagnosticCheck{Name: "Configuration", Status: command.DiagnosticOK, Details: "Valid"}
+
+	if err := config.IsValid(); err != nil {
+		check.Status = command.DiagnosticError
+		check.Details = err.Error()
+		return check
+	}
+	if _, err := config.getIncomingWebhook(""); err != nil {
+		check.Status = command.DiagnosticError
+		check.Details = err.Error()
+	}
+	return check
+}
+
+// checkSiteURL compares the plugin's Site URL with the server's, which OAuth redirects must match
+func (p *Plugin) checkSiteURL(config *configuration) command.DiagnosticCheck {
+	check := command.DiagnosticCheck{Name: "Site URL", Status: command.DiagnosticOK}
+
+	serverSiteURL := ""
+	if siteURL := p.API.GetConfig().ServiceSettings.SiteURL; siteURL != nil {
+		serverSiteURL = strings.TrimRight(*siteURL, "/")
+	}
+	pluginSiteURL := strings.TrimRight(config.SiteURL, "/")
+
+	switch {
+	case serverSiteURL == "" && pluginSiteURL == "":
+		check.Status = command.DiagnosticError
+		check.Details = "Neither the plugin's nor the server's Site URL is set; OAuth redirects will fail"
+	case serverSiteURL == "":
+		check.Status = command.DiagnosticWarning
+		check.Details = "The server's Site URL is not set"
+	case pluginSiteURL == "":
+		check.Details = fmt.Sprintf("Using the server's Site URL `%s`", serverSiteURL)
+	case pluginSiteURL != serverSiteURL:
+		check.Status = command.DiagnosticError
+		check.Details = fmt.Sprintf("Plugin uses `%s` but the server's Site URL is `%s`", pluginSiteURL, serverSiteURL)
+	default:
+		check.Details = serverSiteURL
+	}
+	return check
+}
+
+// checkReachable reports whether an endpoint answers HTTP requests at all
+func checkReachable(name, url string) command.DiagnosticCheck {
+	client := &http.Client{
+		Timeout: diagnosticsProbeTimeout,
+	}
+
+	started := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return command.DiagnosticCheck{Name: name, Status: command.DiagnosticError, Details: err.Error()}
+	}
+	resp.Body.Close()
+
+	return command.DiagnosticCheck{
+		Name:    name,
+		Status:  command.DiagnosticOK,
+		Details: fmt.Sprintf("HTTP %d in %s", resp.StatusCode, time.Since(started).Round(time.Millisecond)),
+	}
+}
+
+// checkServiceAccountToken validates the configured Telemost OAuth token with a read-only call
+func (p *Plugin) checkServiceAccountToken(config *configuration) command.DiagnosticCheck {
+	check := command.DiagnosticCheck{Name: "Telemost OAuth token", Status: command.DiagnosticOK, Details: "Valid"}
+
+	if config.TelemostOAuthToken == "" {
+		check.Status = command.DiagnosticWarning
+		check.Details = "Not configured; the REST API and incoming webhooks can't create meetings"
+		return check
+	}
+
+	if err := NewTelemostClient(config.TelemostOAuthToken, p.API, p.metrics).CheckToken(); err != nil {
+		check.Status = command.DiagnosticError
+		check.Details = err.Error()
+	}
+	return check
+}
+
+func (p *Plugin) checkBackgroundJob() command.DiagnosticCheck {
+	check := command.DiagnosticCheck{Name: "Background job", Status: command.DiagnosticOK}
+
+	var status jobStatus
+	if err := p.client.KV.Get(jobStatusKey, &status); err != nil {
+		check.Status = command.DiagnosticError
+		check.Details = err.Error()
+		return check
+	}
+
+	if status.LastRunAt.IsZero() {
+		check.Status = command.DiagnosticWarning
+		check.Details = "Has not run yet"
+		return check
+	}
+
+	check.Details = fmt.Sprintf("Last run %s, took %s", status.LastRunAt.UTC().Format(time.RFC3339), (time.Duration(status.DurationMs) * time.Millisecond).String())
+	if time.Since(status.LastRunAt) > jobOverdueAfter {
+		check.Status = command.DiagnosticWarning
+		check.Details += " (overdue)"
+	}
+	if p.backgroundJob == nil {
+		check.Status = command.DiagnosticError
+		check.Details += "; not scheduled on this node"
+	}
+	return check
+}
+
+// countRecords counts the plugin's KV records by kind
+func (p *Plugin) countRecords() ([]command.DiagnosticRecordCount, error) {
+	prefixes := []struct {
+		name   string
+		prefix string
+	}{
+		{"Connected users", userTokenKeyPrefix},
+		{"Pending OAuth states", oauthStateKeyPrefix},
+		{"Rate limit buckets", rateLimitKeyPrefix},
+		{"Queued webhook deliveries", webhookDeliveryKeyPrefix},
+		{"Queued token revocations", tokenRevocationKeyPrefix},
+		{"Queued deactivated user cleanups", deactivationKeyPrefix},
+		{"Webhook meetings", webhookMeetingKeyPrefix},
+		{"Meeting records", meetingRecordKeyPrefix},
+		{"Statistics counters", statsKeyPrefix},
+	}
+
+	records := []command.DiagnosticRecordCount{}
+	for _, kind := range prefixes {
+		keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, kind.prefix)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, command.DiagnosticRecordCount{Name: kind.name, Count: len(keys)})
+	}
+
+	count, err := p.audit.Count()
+	if err != nil {
+		return records, err
+	}
+	records = append(records, command.DiagnosticRecordCount{Name: "Audit events", Count: count})
+
+	return records, nil
+}
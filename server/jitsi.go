@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const maxJitsiRoomPrefixLength = 40
+
+var jitsiRoomInvalidChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// jitsiProvider creates meetings as rooms on a Jitsi server. Jitsi creates rooms when the first
+// participant joins, so meetings are static links and no API is called.
+type jitsiProvider struct {
+	baseURL string
+}
+
+func newJitsiProvider(baseURL string) *jitsiProvider {
+	return &jitsiProvider{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Name returns "jitsi"
+func (jp *jitsiProvider) Name() string {
+	return providerJitsi
+}
+
+// Capabilities returns that Jitsi rooms support none of the optional features
+func (jp *jitsiProvider) Capabilities() providerCapabilities {
+	return providerCapabilities{}
+}
+
+// CreateMeetingWithDefaults names a new room after the title with a random suffix, so links
+// can't be guessed
+func (jp *jitsiProvider) CreateMeetingWithDefaults(_ meetingDefaults, title string, _ string, _ []string) (*TelemostMeeting, error) {
+	var prefix strings.Builder
+	for _, word := range strings.Fields(jitsiRoomInvalidChars.ReplaceAllString(title, " ")) {
+		prefix.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+	}
+
+	room := prefix.String()
+	if len(room) > maxJitsiRoomPrefixLength {
+		room = room[:maxJitsiRoomPrefixLength]
+	}
+	return jp.GetMeeting(room + model.NewId())
+}
+
+// GetMeeting returns the link of a room
+func (jp *jitsiProvider) GetMeeting(meetingID string) (*TelemostMeeting, error) {
+	return &TelemostMeeting{
+		ID:      meetingID,
+		JoinURL: jp.baseURL + "/" + url.PathEscape(meetingID),
+	}, nil
+}
+
+// UpdateLiveStream is not supported
+func (jp *jitsiProvider) UpdateLiveStream(string, string) (*TelemostMeeting, error) {
+	return nil, &command.UnsupportedError{Provider: providerDisplayNames[providerJitsi], Feature: "live streams"}
+}
+
+// EndMeeting is not supported
+func (jp *jitsiProvider) EndMeeting(string) error {
+	return &command.UnsupportedError{Provider: providerDisplayNames[providerJitsi], Feature: "ending meetings"}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// liveStreamDefaults applies a meeting's live stream choice to the configured defaults. An
+// explicit request for a stream fails when the user isn't allowed to stream.
+func liveStreamDefaults(config meetingDefaults, permissions *meetingPermissions, liveStream string) (meetingDefaults, error) {
+	switch liveStream {
+	case "":
+		if !permissions.AllowLiveStream {
+			return withoutLiveStream{config}, nil
+		}
+		return config, nil
+	case command.LiveStreamOff:
+		return withoutLiveStream{config}, nil
+	default:
+		if !permissions.AllowLiveStream {
+			return nil, &command.PermissionError{Reason: "live streams are restricted to system admins"}
+		}
+		return withLiveStream{config, liveStream}, nil
+	}
+}
+
+// UpdateLiveStream starts or stops the live stream of a meeting
+func (s *meetingService) UpdateLiveStream(req *command.LiveStreamRequest) (*command.TelemostMeeting, error) {
+	provider, err := s.p.existingMeetingProvider(req.UserID, req.ChannelID, req.MeetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "stop"
+	accessLevel := ""
+	if req.AccessLevel != command.LiveStreamOff {
+		if !s.p.canLiveStream(req.UserID) {
+			return nil, &command.PermissionError{Reason: "live streams are restricted to system admins"}
+		}
+		action = "start"
+		accessLevel = req.AccessLevel
+		if accessLevel == "" {
+			accessLevel = s.p.getConfiguration().GetDefaultLiveStreamAccessLevel()
+		}
+		if accessLevel == "" {
+			accessLevel = command.LiveStreamPublic
+		}
+	}
+
+	meeting, err := provider.UpdateLiveStream(req.MeetingID, accessLevel)
+	if err != nil {
+		s.p.recordAudit(audit.EventLiveStream, req.UserID, req.ChannelID, false, map[string]string{"action": action, "meeting_id": req.MeetingID, "error": err.Error()})
+		return nil, err
+	}
+	s.p.recordAudit(audit.EventLiveStream, req.UserID, req.ChannelID, true, map[string]string{"action": action, "meeting_id": req.MeetingID})
+
+	if action == "start" {
+		title := ""
+		if lastMeeting, err := s.p.getLastMeeting(req.UserID); err == nil && lastMeeting != nil && lastMeeting.ID == req.MeetingID {
+			title = lastMeeting.Title
+		}
+		s.p.announceLiveStream(req.UserID, req.ChannelID, title, meeting)
+	}
+
+	return meeting, nil
+}
+
+// GetMeeting returns a meeting read as the user
+func (s *meetingService) GetMeeting(userID, channelID, meetingID string) (*command.TelemostMeeting, error) {
+	provider, err := s.p.existingMeetingProvider(userID, channelID, meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetMeeting(meetingID)
+}
+
+// announceLiveStream posts the watch link of a meeting's live stream to the configured broadcast
+// channel, separately from the join link posted for participants
+func (p *Plugin) announceLiveStream(userID, channelID, title string, meeting *TelemostMeeting) {
+	if meeting.LiveStream == nil || meeting.LiveStream.WatchURL == "" {
+		return
+	}
+
+	channel, err := p.resolveBroadcastChannel(channelID)
+	if err != nil {
+		p.API.LogError("Failed to find the live stream broadcast channel", "error", err.Error())
+		return
+	}
+	if channel == nil {
+		return
+	}
+
+	if title == "" {
+		title = defaultMeetingTitle
+	}
+	message := fmt.Sprintf("📺 **%s** is live\n\n[Watch the live stream](%s)", title, meeting.LiveStream.WatchURL)
+	if userID != "" {
+		message += fmt.Sprintf("\n\nStreamed by @%s", p.displayUsername(userID))
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+	}
+	post.AddProp("meetingID", meeting.ID)
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		p.API.LogError("Failed to post live stream announcement", "channel_id", channel.Id, "error", appErr.Error())
+	}
+}
+
+// resolveBroadcastChannel finds the configured broadcast channel. Names are looked up in the team
+// of the meeting's channel. It returns nil when no broadcast channel is configured.
+func (p *Plugin) resolveBroadcastChannel(originChannelID string) (*model.Channel, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(p.getConfiguration().LiveStreamBroadcastChannel), "~")
+	if name == "" {
+		return nil, nil
+	}
+
+	if model.IsValidId(name) {
+		channel, appErr := p.API.GetChannel(name)
+		if appErr != nil {
+			return nil, appErr
+		}
+		return channel, nil
+	}
+
+	if originChannelID == "" {
+		return nil, fmt.Errorf("channel %q can't be resolved for a meeting without a channel", name)
+	}
+	origin, appErr := p.API.GetChannel(originChannelID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if origin.TeamId == "" {
+		return nil, fmt.Errorf("channel %q can't be resolved from a direct or group message", name)
+	}
+
+	channel, appErr := p.API.GetChannelByName(origin.TeamId, strings.ToLower(name), false)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return channel, nil
+}
@@ -49,11 +49,21 @@ const manifestStr = `
         "hosting": "",
         "secret": false
       },
+      {
+        "key": "YandexClientSecret",
+        "display_name": "Yandex OAuth Client Secret",
+        "type": "text",
+        "help_text": "The OAuth client secret from your Yandex application. When set, users connect with the authorization code flow and their tokens are refreshed automatically before they expire.",
+        "placeholder": "Enter your Yandex OAuth client secret",
+        "default": "",
+        "hosting": "",
+        "secret": true
+      },
       {
         "key": "SiteURL",
         "display_name": "Mattermost Site URL",
         "type": "text",
-        "help_text": "The URL of your Mattermost server (e.g., https://mattermost.example.com). Required for OAuth redirects.",
+        "help_text": "Overrides the server's Site URL for OAuth redirects (e.g., https://mattermost.example.com). Leave empty to use the Site URL from System Console \u003e Environment \u003e Web Server.",
         "placeholder": "https://mattermost.example.com",
         "default": "",
         "hosting": "",
@@ -97,7 +107,7 @@ const manifestStr = `
         "key": "EnableLiveStream",
         "display_name": "Enable Live Stream",
         "type": "bool",
-        "help_text": "Enable live streaming capability for meetings. Requires appropriate Telemost plan.",
+        "help_text": "Stream new meetings by default. Users can opt individual meetings in or out with '/telemost start --stream public|org|off'. Requires appropriate Telemost plan.",
         "placeholder": "",
         "default": false,
         "hosting": "",
@@ -122,6 +132,216 @@ const manifestStr = `
         ],
         "hosting": "",
         "secret": false
+      },
+      {
+        "key": "LiveStreamBroadcastChannel",
+        "display_name": "Live Stream Broadcast Channel",
+        "type": "text",
+        "help_text": "Channel where the watch link of live streams is posted, separately from the participants' join link. Enter a channel ID or a channel name such as '~town-square', looked up in the team of the meeting's channel. Leave empty to not post watch links.",
+        "placeholder": "~town-square",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "MeetingRateLimitPerUser",
+        "display_name": "Meetings Per User Per Hour",
+        "type": "number",
+        "help_text": "Maximum number of meetings a single user can create per hour. Set to 0 to disable the limit.",
+        "placeholder": "",
+        "default": 10,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "MeetingRateLimitPerChannel",
+        "display_name": "Meetings Per Channel Per Hour",
+        "type": "number",
+        "help_text": "Maximum number of meetings that can be created in a single channel per hour. Set to 0 to disable the limit.",
+        "placeholder": "",
+        "default": 20,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AuditRetentionDays",
+        "display_name": "Audit Log Retention (Days)",
+        "type": "number",
+        "help_text": "Number of days audit events are kept before the background job deletes them. Set to 0 to keep them forever.",
+        "placeholder": "",
+        "default": 90,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AutoEndMeetingsHours",
+        "display_name": "Auto-End Meetings After (Hours)",
+        "type": "number",
+        "help_text": "End and delete meetings created through the plugin once they are older than this many hours, unless they were started with --persistent or from a persistent template. Their posts are marked as expired. Set to 0 to keep meetings until they are ended.",
+        "placeholder": "",
+        "default": 0,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "TokenExpiryReminderHours",
+        "display_name": "Token Expiry Reminder (Hours)",
+        "type": "number",
+        "help_text": "Send users a direct message with a reconnect link when their Telemost token expires within this many hours and can't be refreshed. Set to 0 to disable reminders.",
+        "placeholder": "",
+        "default": 24,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AllowedTeams",
+        "display_name": "Restrict Meetings to Teams",
+        "type": "text",
+        "help_text": "Comma-separated team names or IDs where meetings can be created. Leave empty to allow all teams.",
+        "placeholder": "engineering, support",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AllowedChannels",
+        "display_name": "Restrict Meetings to Channels",
+        "type": "text",
+        "help_text": "Comma-separated channel names or IDs where meetings can be created. Leave empty to allow all channels.",
+        "placeholder": "town-square, standup",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AllowedRoles",
+        "display_name": "Restrict Meetings to Roles",
+        "type": "text",
+        "help_text": "Comma-separated Mattermost system, team or channel roles allowed to create meetings, e.g. system_admin, team_admin, channel_admin. Leave empty to allow all users.",
+        "placeholder": "system_admin, team_admin",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "AllowedGroups",
+        "display_name": "Restrict Meetings to Groups",
+        "type": "text",
+        "help_text": "Comma-separated user group names or IDs whose members can create meetings. Leave empty to allow all users.",
+        "placeholder": "leadership",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "BlockPublicChannels",
+        "display_name": "Block Meetings in Public Channels",
+        "type": "bool",
+        "help_text": "Prevent meetings from being created in public channels.",
+        "placeholder": "",
+        "default": false,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "BlockSharedChannels",
+        "display_name": "Block Meetings in Shared Channels",
+        "type": "bool",
+        "help_text": "Prevent meetings from being created in channels shared with other Mattermost servers.",
+        "placeholder": "",
+        "default": false,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "RestrictLiveStreamToAdmins",
+        "display_name": "Restrict Live Streams to Admins",
+        "type": "bool",
+        "help_text": "Only system administrators can create meetings with a live stream. Meetings created by other users never start a live stream.",
+        "placeholder": "",
+        "default": false,
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "IncomingWebhooks",
+        "display_name": "Incoming Webhook Integrations",
+        "type": "longtext",
+        "help_text": "JSON list of external systems allowed to create meetings through the plugin, e.g. [{\"name\": \"helpdesk\", \"secret\": \"\u003crandom secret\u003e\", \"channels\": [\"\u003cchannel id\u003e\"]}]. Each integration posts to /plugins/com.mattermost.plugin-telemost/webhooks/incoming/\u003cname\u003e. Meetings are created with the legacy Telemost OAuth token.",
+        "placeholder": "",
+        "default": "",
+        "hosting": "",
+        "secret": true
+      },
+      {
+        "key": "OutgoingWebhookURLs",
+        "display_name": "Outgoing Webhook URLs",
+        "type": "longtext",
+        "help_text": "URLs receiving meeting.created, meeting.ended, user.connected and user.disconnected events as signed JSON POST requests, separated by commas or new lines.",
+        "placeholder": "https://analytics.example.com/telemost",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "OutgoingWebhookSecret",
+        "display_name": "Outgoing Webhook Secret",
+        "type": "text",
+        "help_text": "Secret used to sign outgoing webhook requests. Leave empty to send unsigned requests.",
+        "placeholder": "",
+        "default": "",
+        "hosting": "",
+        "secret": true
+      },
+      {
+        "key": "IncidentChannelNameTemplate",
+        "display_name": "Incident Channel Name Template",
+        "type": "text",
+        "help_text": "Name of the private channels created by /telemost incident. Supports {{name}} (the incident title) and the {{date}}, {{time}} and {{weekday}} variables of meeting templates, in your timezone. Characters not allowed in channel names become dashes.",
+        "placeholder": "incident-{{date}}-{{name}}",
+        "default": "incident-{{date}}-{{name}}",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "JitsiURL",
+        "display_name": "Jitsi Server URL",
+        "type": "text",
+        "help_text": "Jitsi server hosting meetings for the teams and channels listed below, e.g. https://meet.jit.si. Jitsi meetings are room links and don't support cohosts, waiting rooms, live streams or ending meetings.",
+        "placeholder": "https://meet.jit.si",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "JitsiTeams",
+        "display_name": "Jitsi Teams",
+        "type": "text",
+        "help_text": "Comma-separated team names or IDs whose meetings are hosted on Jitsi instead of Telemost.",
+        "placeholder": "support, partners",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "JitsiChannels",
+        "display_name": "Jitsi Channels",
+        "type": "text",
+        "help_text": "Comma-separated channel names or IDs whose meetings are hosted on Jitsi instead of Telemost.",
+        "placeholder": "external-standup",
+        "default": "",
+        "hosting": "",
+        "secret": false
+      },
+      {
+        "key": "MeetingTemplates",
+        "display_name": "Meeting Templates",
+        "type": "longtext",
+        "help_text": "JSON list of named meeting templates users can start with '/telemost start --template \u003cname\u003e', e.g. [{\"name\": \"all-hands\", \"title\": \"All Hands {{date}}\", \"waiting_room_level\": \"ADMINS\", \"live_stream\": \"ORGANIZATION\", \"cohost_groups\": [\"leadership\"]}]. Titles and descriptions support {{date}}, {{time}}, {{weekday}}, {{channel}}, {{team}}, {{user}} and {{user_full_name}}. Other fields are description, cohosts (emails) and live_stream 'off'.",
+        "placeholder": "",
+        "default": "",
+        "hosting": "",
+        "secret": false
       }
     ],
     "sections": null
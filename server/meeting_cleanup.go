@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const meetingCleanupMutexKey = "telemost_meeting_cleanup"
+
+// endMeeting ends a meeting with its provider for all participants, audits it, marks its record
+// and post as ended and notifies the outgoing webhooks
+func (p *Plugin) endMeeting(provider MeetingProvider, userID, channelID, meetingID, source string) error {
+	if err := provider.EndMeeting(meetingID); err != nil {
+		p.recordAudit(audit.EventMeetingEnd, userID, channelID, false, map[string]string{"meeting_id": meetingID, "source": source, "error": err.Error()})
+		return err
+	}
+	p.recordAudit(audit.EventMeetingEnd, userID, channelID, true, map[string]string{"meeting_id": meetingID, "source": source, "provider": provider.Name()})
+	p.markMeetingEnded(meetingID)
+	p.emitEvent(eventMeetingEnded, map[string]interface{}{
+		"meeting_id": meetingID,
+		"user_id":    userID,
+		"channel_id": channelID,
+		"source":     source,
+		"provider":   provider.Name(),
+	})
+
+	return nil
+}
+
+// endStaleMeetings enforces the auto-end policy: active meetings created through the plugin that
+// are older than the configured age are ended, unless they are persistent. A summary of each run
+// is logged.
+func (p *Plugin) endStaleMeetings() {
+	hours := p.getConfiguration().AutoEndMeetingsHours
+	if hours <= 0 {
+		return
+	}
+
+	// Make sure only one server ends meetings at a time, even when runs overlap
+	mutex, err := cluster.NewMutex(p.API, meetingCleanupMutexKey)
+	if err != nil {
+		p.API.LogError("Failed to create meeting cleanup mutex", "error", err.Error())
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	cutoff := time.Now().Add(-time.Duration(hours) * time.Hour)
+	records, err := p.listMeetingRecords(func(record *command.MeetingRecord) bool {
+		return record.State == command.MeetingStateActive && record.CreatedAt.Before(cutoff)
+	})
+	if err != nil {
+		p.API.LogError("Failed to list meeting records", "error", err.Error())
+		return
+	}
+
+	var ended, alreadyEnded, endedLocally, persistent, unsupported, failed int
+	for i := range records {
+		record := &records[i]
+		if record.Persistent {
+			persistent++
+			continue
+		}
+		if !namedProviderCapabilities(record.Provider).End {
+			unsupported++
+			continue
+		}
+
+		provider, asServiceAccount, err := p.cleanupMeetingProvider(record)
+		if err != nil {
+			// Nobody can end the meeting with its provider, so it would be retried forever
+			p.API.LogWarn("No credentials to end stale meeting, ending it locally", "meeting_id", record.ID, "creator_id", record.CreatorID)
+			p.markMeetingEnded(record.ID)
+			endedLocally++
+			continue
+		}
+
+		err = p.endMeeting(provider, record.CreatorID, record.ChannelID, record.ID, "cleanup")
+		var apiErr *TelemostAPIError
+		switch {
+		case err == nil:
+			ended++
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			// Deleted outside the plugin, only the record and post are left to update
+			p.markMeetingEnded(record.ID)
+			alreadyEnded++
+		case asServiceAccount && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+			// The service account can't end meetings it doesn't own, and won't be able to later
+			p.API.LogWarn("Service account may not end stale meeting, ending it locally", "meeting_id", record.ID, "creator_id", record.CreatorID)
+			p.markMeetingEnded(record.ID)
+			endedLocally++
+		default:
+			p.API.LogWarn("Failed to end stale meeting", "meeting_id", record.ID, "error", err.Error())
+			failed++
+		}
+	}
+
+	p.API.LogInfo("Meeting cleanup finished",
+		"max_age_hours", hours,
+		"ended", ended,
+		"already_ended", alreadyEnded,
+		"ended_locally", endedLocally,
+		"persistent", persistent,
+		"unsupported", unsupported,
+		"failed", failed,
+	)
+}
+
+// cleanupMeetingProvider returns the provider to end a stale meeting with: as its creator, or as
+// the service account for Telemost meetings created by incoming webhooks or whose creator is no
+// longer connected
+func (p *Plugin) cleanupMeetingProvider(record *command.MeetingRecord) (provider MeetingProvider, asServiceAccount bool, err error) {
+	provider, err = p.namedMeetingProvider(record.Provider, record.CreatorID)
+	if err == nil {
+		return provider, false, nil
+	}
+	if record.Provider == providerTelemost && p.telemostClient != nil {
+		return p.telemostClient, true, nil
+	}
+	return nil, false, err
+}
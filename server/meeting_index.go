@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+)
+
+// The meeting records of a channel and of a creator are indexed as lists of meeting IDs, oldest
+// first, so /telemost list and the other per channel or per user lookups don't load every record.
+// The prefixes don't start with meetingRecordKeyPrefix, which would list them as records.
+const (
+	meetingChannelIndexKeyPrefix = "telemost_meetings_channel_"
+	meetingCreatorIndexKeyPrefix = "telemost_meetings_creator_"
+
+	// meetingIndexBuiltKey marks that the records stored before the indexes existed were indexed
+	meetingIndexBuiltKey   = "telemost_meetings_indexed"
+	meetingIndexMutexKey   = "telemost_meetings_index"
+	meetingIndexBuiltValue = "1"
+)
+
+// indexMeetingRecord adds a meeting record to the indexes of its channel and creator. Meetings
+// created by incoming webhooks have no creator to index.
+func (p *Plugin) indexMeetingRecord(record *command.MeetingRecord) error {
+	if err := p.addToMeetingIndex(meetingChannelIndexKeyPrefix+record.ChannelID, record.ID); err != nil {
+		return err
+	}
+	if record.CreatorID == "" {
+		return nil
+	}
+	return p.addToMeetingIndex(meetingCreatorIndexKeyPrefix+record.CreatorID, record.ID)
+}
+
+// deleteMeetingRecord deletes a meeting record and removes it from the indexes
+func (p *Plugin) deleteMeetingRecord(record *command.MeetingRecord) error {
+	if err := p.client.KV.Delete(meetingRecordKeyPrefix + record.ID); err != nil {
+		return err
+	}
+	if err := p.removeFromMeetingIndex(meetingChannelIndexKeyPrefix+record.ChannelID, record.ID); err != nil {
+		return err
+	}
+	if record.CreatorID == "" {
+		return nil
+	}
+	return p.removeFromMeetingIndex(meetingCreatorIndexKeyPrefix+record.CreatorID, record.ID)
+}
+
+// meetingIndex returns the meeting IDs of an index, oldest first
+func (p *Plugin) meetingIndex(key string) ([]string, error) {
+	ids := []string{}
+	if err := p.client.KV.Get(key, &ids); err != nil {
+		return nil, errors.Wrap(err, "failed to load meeting index")
+	}
+	return ids, nil
+}
+
+func (p *Plugin) addToMeetingIndex(key, meetingID string) error {
+	return p.updateMeetingIndex(key, func(ids []string) []string {
+		for _, id := range ids {
+			if id == meetingID {
+				return ids
+			}
+		}
+		return append(ids, meetingID)
+	})
+}
+
+func (p *Plugin) removeFromMeetingIndex(key, meetingID string) error {
+	return p.updateMeetingIndex(key, func(ids []string) []string {
+		for i, id := range ids {
+			if id == meetingID {
+				return append(ids[:i], ids[i+1:]...)
+			}
+		}
+		return ids
+	})
+}
+
+// updateMeetingIndex atomically changes an index, deleting it when it becomes empty
+func (p *Plugin) updateMeetingIndex(key string, update func(ids []string) []string) error {
+	return p.client.KV.SetAtomicWithRetries(key, func(oldValue []byte) (interface{}, error) {
+		ids := []string{}
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, &ids); err != nil {
+				return nil, err
+			}
+		}
+		ids = update(ids)
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		return ids, nil
+	})
+}
+
+// listIndexedMeetingRecords returns the records of an index matching filter, newest first
+func (p *Plugin) listIndexedMeetingRecords(key string, filter func(record *command.MeetingRecord) bool) ([]command.MeetingRecord, error) {
+	ids, err := p.meetingIndex(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.loadMeetingRecords(reversed(ids), filter)
+}
+
+// loadMeetingRecords returns the records of the given meetings matching filter, in the same
+// order. Meetings whose record is gone are skipped.
+func (p *Plugin) loadMeetingRecords(ids []string, filter func(record *command.MeetingRecord) bool) ([]command.MeetingRecord, error) {
+	records := []command.MeetingRecord{}
+	for _, id := range ids {
+		record, err := p.getMeetingRecord(id)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil && filter(record) {
+			records = append(records, *record)
+		}
+	}
+	return records, nil
+}
+
+// ensureMeetingIndexes indexes the meeting records stored before the indexes existed. It runs
+// once, on whichever server gets there first.
+func (p *Plugin) ensureMeetingIndexes() error {
+	mutex, err := cluster.NewMutex(p.API, meetingIndexMutexKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create meeting index mutex")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var built string
+	if err := p.client.KV.Get(meetingIndexBuiltKey, &built); err != nil {
+		return errors.Wrap(err, "failed to load meeting index marker")
+	}
+	if built == meetingIndexBuiltValue {
+		return nil
+	}
+
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, meetingRecordKeyPrefix)
+	if err != nil {
+		return err
+	}
+	records := []command.MeetingRecord{}
+	for _, key := range keys {
+		var record command.MeetingRecord
+		if err := p.client.KV.Get(key, &record); err != nil {
+			return err
+		}
+		if record.ID != "" {
+			records = append(records, record)
+		}
+	}
+
+	// Indexes are kept oldest first
+	sortMeetingRecords(records)
+	for i := len(records) - 1; i >= 0; i-- {
+		if err := p.indexMeetingRecord(&records[i]); err != nil {
+			return errors.Wrapf(err, "failed to index meeting %s", records[i].ID)
+		}
+	}
+
+	if _, err := p.client.KV.Set(meetingIndexBuiltKey, meetingIndexBuiltValue); err != nil {
+		return errors.Wrap(err, "failed to store meeting index marker")
+	}
+	if len(records) > 0 {
+		p.API.LogInfo("Indexed meeting records", "count", len(records))
+	}
+	return nil
+}
+
+func reversed(ids []string) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[len(ids)-1-i] = id
+	}
+	return result
+}
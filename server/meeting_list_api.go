@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// handleMeetingListAction handles the Rejoin, End and paging buttons of /telemost list. After
+// ending a meeting or paging, the list is rendered again in place.
+func (p *Plugin) handleMeetingListAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	action, _ := req.Context["action"].(string)
+	meetingID, _ := req.Context["meeting_id"].(string)
+	scope, _ := req.Context["scope"].(string)
+	page, _ := req.Context["page"].(float64)
+
+	if scope == command.MeetingListAll && !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Only system administrators can list all meetings", http.StatusForbidden)
+		return
+	}
+	if !p.API.HasPermissionToChannel(userID, req.ChannelId, model.PermissionReadChannel) {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
+	response := &model.PostActionIntegrationResponse{}
+	switch action {
+	case command.MeetingListActionPage:
+		// The requested page is rendered below
+
+	case command.MeetingListActionRejoin, command.MeetingListActionEnd:
+		record, err := p.getMeetingRecord(meetingID)
+		if err != nil || record == nil {
+			http.Error(w, "Meeting not found", http.StatusNotFound)
+			return
+		}
+		if record.CreatorID != userID && !p.API.HasPermissionToChannel(userID, record.ChannelID, model.PermissionReadChannel) {
+			http.Error(w, "Meeting not found", http.StatusNotFound)
+			return
+		}
+
+		if action == command.MeetingListActionRejoin {
+			response.EphemeralText = fmt.Sprintf("[Join **%s**](%s)", record.Title, record.JoinURL)
+			break
+		}
+
+		if err := p.meetings.EndMeeting(userID, record.ChannelID, record.ID); err != nil {
+			response.EphemeralText = command.MeetingErrorMessage(err)
+			if response.EphemeralText == "" {
+				response.EphemeralText = fmt.Sprintf("Failed to end the meeting: %s", err.Error())
+			}
+			break
+		}
+		response.EphemeralText = fmt.Sprintf("Meeting **%s** has ended for all participants.", record.Title)
+
+	default:
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+
+	if action != command.MeetingListActionRejoin {
+		text, attachments, err := p.commandClient.RenderMeetingList(userID, req.ChannelId, scope, int(page))
+		if err != nil {
+			p.API.LogError("Failed to render meeting list", "user_id", userID, "error", err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		update := &model.Post{Message: text}
+		model.ParseSlackAttachment(update, attachments)
+		response.Update = update
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
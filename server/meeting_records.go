@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/pkg/errors"
+)
+
+const (
+	meetingRecordKeyPrefix = "telemost_meeting_"
+
+	// meetingRecordRetention is how long records of ended meetings are kept for /telemost list
+	meetingRecordRetention = 30 * 24 * time.Hour
+)
+
+// storeMeetingRecord records a meeting created through the plugin for /telemost list
+func (p *Plugin) storeMeetingRecord(req *command.MeetingRequest, provider string, meeting *TelemostMeeting) {
+	record := command.MeetingRecord{
+		ID:         meeting.ID,
+		JoinURL:    meeting.JoinURL,
+		Title:      req.Title,
+		CreatorID:  req.UserID,
+		ChannelID:  req.ChannelID,
+		Provider:   provider,
+		State:      command.MeetingStateActive,
+		Persistent: req.Persistent,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := p.client.KV.Set(meetingRecordKeyPrefix+meeting.ID, record); err != nil {
+		p.API.LogError("Failed to store meeting record", "meeting_id", meeting.ID, "error", err.Error())
+		return
+	}
+	if err := p.indexMeetingRecord(&record); err != nil {
+		p.API.LogError("Failed to index meeting record", "meeting_id", meeting.ID, "error", err.Error())
+	}
+}
+
+// getMeetingRecord returns the record of a meeting, or nil if it wasn't created through the plugin
+func (p *Plugin) getMeetingRecord(meetingID string) (*command.MeetingRecord, error) {
+	var record command.MeetingRecord
+	if err := p.client.KV.Get(meetingRecordKeyPrefix+meetingID, &record); err != nil {
+		return nil, err
+	}
+	if record.ID == "" {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// updateMeetingRecord atomically changes the record of a meeting, if there is one
+func (p *Plugin) updateMeetingRecord(meetingID string, update func(record *command.MeetingRecord)) error {
+	record, err := p.getMeetingRecord(meetingID)
+	if err != nil || record == nil {
+		return err
+	}
+
+	return p.client.KV.SetAtomicWithRetries(meetingRecordKeyPrefix+meetingID, func(oldValue []byte) (interface{}, error) {
+		if oldValue == nil {
+			return nil, errors.New("meeting record was deleted")
+		}
+
+		var record command.MeetingRecord
+		if err := json.Unmarshal(oldValue, &record); err != nil {
+			return nil, err
+		}
+		update(&record)
+		return record, nil
+	})
+}
+
+// markMeetingEnded records that a meeting has ended and marks the post announcing it as expired
+func (p *Plugin) markMeetingEnded(meetingID string) {
+	postID := ""
+	err := p.updateMeetingRecord(meetingID, func(record *command.MeetingRecord) {
+		if record.State != command.MeetingStateEnded {
+			record.State = command.MeetingStateEnded
+			record.EndedAt = time.Now()
+		}
+		postID = record.PostID
+	})
+	if err != nil {
+		p.API.LogError("Failed to mark meeting as ended", "meeting_id", meetingID, "error", err.Error())
+		return
+	}
+
+	if postID != "" {
+		p.expireMeetingPost(postID)
+	}
+}
+
+// expireMeetingPost marks a meeting post as expired, so the meeting card stops offering to join
+func (p *Plugin) expireMeetingPost(postID string) {
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		p.API.LogWarn("Failed to get meeting post", "post_id", postID, "error", appErr.Error())
+		return
+	}
+	if expired, _ := post.GetProp("expired").(bool); expired {
+		return
+	}
+
+	post.AddProp("expired", true)
+	if _, appErr := p.API.UpdatePost(post); appErr != nil {
+		p.API.LogError("Failed to mark meeting post as expired", "post_id", postID, "error", appErr.Error())
+	}
+}
+
+// listMeetingRecords returns the meeting records matching filter, newest first. It loads every
+// record, lookups by channel or creator use listIndexedMeetingRecords instead.
+func (p *Plugin) listMeetingRecords(filter func(record *command.MeetingRecord) bool) ([]command.MeetingRecord, error) {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, meetingRecordKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []command.MeetingRecord{}
+	for _, key := range keys {
+		var record command.MeetingRecord
+		if err := p.client.KV.Get(key, &record); err != nil {
+			return nil, err
+		}
+		if record.ID != "" && filter(&record) {
+			records = append(records, record)
+		}
+	}
+
+	sortMeetingRecords(records)
+	return records, nil
+}
+
+// sortMeetingRecords sorts meeting records newest first
+func sortMeetingRecords(records []command.MeetingRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+}
+
+// GetMeetingRecord returns the record of a meeting, or nil if it wasn't created through the plugin
+func (s *meetingService) GetMeetingRecord(meetingID string) (*command.MeetingRecord, error) {
+	return s.p.getMeetingRecord(meetingID)
+}
+
+// ListMeetings returns a page of the meetings created through the plugin. Only the records of
+// the page are loaded for the channel and mine scopes. Providers aren't asked about the meetings,
+// the background job refreshes their state.
+func (s *meetingService) ListMeetings(query *command.MeetingListQuery) (*command.MeetingList, error) {
+	var ids []string
+	switch query.Scope {
+	case command.MeetingListMine:
+		index, err := s.p.meetingIndex(meetingCreatorIndexKeyPrefix + query.UserID)
+		if err != nil {
+			return nil, err
+		}
+		ids = reversed(index)
+	case command.MeetingListAll:
+		records, err := s.p.listMeetingRecords(func(*command.MeetingRecord) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+	default:
+		index, err := s.p.meetingIndex(meetingChannelIndexKeyPrefix + query.ChannelID)
+		if err != nil {
+			return nil, err
+		}
+		ids = reversed(index)
+	}
+
+	list := &command.MeetingList{Total: len(ids)}
+	start := query.Page * query.PerPage
+	if start < 0 || start >= len(ids) {
+		return list, nil
+	}
+	end := start + query.PerPage
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	records, err := s.p.loadMeetingRecords(ids[start:end], func(*command.MeetingRecord) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		list.Meetings = append(list.Meetings, command.MeetingListItem{
+			MeetingRecord: record,
+			CanEnd:        namedProviderCapabilities(record.Provider).End,
+		})
+	}
+	return list, nil
+}
+
+// refreshMeetingStates marks the active meetings their provider no longer knows as ended
+func (p *Plugin) refreshMeetingStates() {
+	records, err := p.listMeetingRecords(func(record *command.MeetingRecord) bool {
+		return record.State == command.MeetingStateActive && namedProviderCapabilities(record.Provider).End
+	})
+	if err != nil {
+		p.API.LogError("Failed to list meeting records", "error", err.Error())
+		return
+	}
+
+	for i := range records {
+		p.refreshMeetingState(&records[i])
+	}
+}
+
+// refreshMeetingState marks an active meeting as ended when its provider no longer knows it. The
+// meeting is looked up like the auto-end policy ends it: as its creator, or as the service account
+// for webhook meetings and meetings of disconnected creators.
+func (p *Plugin) refreshMeetingState(record *command.MeetingRecord) {
+	provider, _, err := p.cleanupMeetingProvider(record)
+	if err != nil {
+		return
+	}
+
+	_, err = provider.GetMeeting(record.ID)
+	var apiErr *TelemostAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		return
+	}
+
+	p.markMeetingEnded(record.ID)
+}
+
+// MessageHasBeenPosted links meeting records to the posts announcing them, whichever entry point
+// created the meeting
+func (p *Plugin) MessageHasBeenPosted(_ *plugin.Context, post *model.Post) {
+	if post.Type != command.MeetingPostType {
+		return
+	}
+	meetingID, _ := post.GetProp("meetingID").(string)
+	if meetingID == "" {
+		return
+	}
+
+	err := p.updateMeetingRecord(meetingID, func(record *command.MeetingRecord) {
+		if record.PostID == "" {
+			record.PostID = post.Id
+		}
+	})
+	if err != nil {
+		p.API.LogError("Failed to link meeting record to post", "meeting_id", meetingID, "post_id", post.Id, "error", err.Error())
+	}
+}
+
+// expireMeetingRecords deletes the records of meetings that ended more than the retention ago
+func (p *Plugin) expireMeetingRecords() {
+	cutoff := time.Now().Add(-meetingRecordRetention)
+	records, err := p.listMeetingRecords(func(record *command.MeetingRecord) bool {
+		return record.State == command.MeetingStateEnded && record.EndedAt.Before(cutoff)
+	})
+	if err != nil {
+		p.API.LogError("Failed to list meeting records", "error", err.Error())
+		return
+	}
+
+	for _, record := range records {
+		if err := p.deleteMeetingRecord(&record); err != nil {
+			p.API.LogError("Failed to delete meeting record", "meeting_id", record.ID, "error", err.Error())
+		}
+	}
+	if len(records) > 0 {
+		p.API.LogInfo("Expired meeting records", "count", len(records))
+	}
+}
@@ -0,0 +1,137 @@
+// Package metrics collects counters and histograms about the plugin with the Prometheus client
+// library. Values are kept in memory and are per server node.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "telemost"
+
+// durationBuckets are the histogram buckets, in seconds, used for latencies
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics holds the plugin's metrics in a registry of their own, so they don't mix with the
+// server's. All methods are safe to call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	telemostRequests        *prometheus.CounterVec
+	telemostRequestDuration *prometheus.HistogramVec
+	telemostRetries         *prometheus.CounterVec
+	oauthFlows              *prometheus.CounterVec
+	commands                *prometheus.CounterVec
+	jobRuns                 *prometheus.CounterVec
+	jobDuration             *prometheus.HistogramVec
+}
+
+// New creates the plugin's metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		telemostRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_requests_total",
+			Help:      "Telemost API requests by method, endpoint and status code.",
+		}, []string{"method", "endpoint", "status"}),
+		telemostRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of Telemost API requests.",
+			Buckets:   durationBuckets,
+		}, []string{"method", "endpoint"}),
+		telemostRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_retries_total",
+			Help:      "Telemost API requests retried after a transient failure.",
+		}, []string{"method", "endpoint"}),
+		oauthFlows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "oauth_flows_total",
+			Help:      "OAuth flows by result: started, completed or failed.",
+		}, []string{"result"}),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_invocations_total",
+			Help:      "Slash command invocations by subcommand.",
+		}, []string{"subcommand"}),
+		jobRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "job_runs_total",
+			Help:      "Background job runs.",
+		}, []string{"job"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Duration of background job runs.",
+			Buckets:   durationBuckets,
+		}, []string{"job"}),
+	}
+
+	m.registry.MustRegister(
+		m.telemostRequests,
+		m.telemostRequestDuration,
+		m.telemostRetries,
+		m.oauthFlows,
+		m.commands,
+		m.jobRuns,
+		m.jobDuration,
+	)
+	return m
+}
+
+// ObserveTelemostRequest records a Telemost API request. status is the HTTP status code, or
+// "error" when no response was received.
+func (m *Metrics) ObserveTelemostRequest(method, endpoint, status string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.telemostRequests.WithLabelValues(method, endpoint, status).Inc()
+	m.telemostRequestDuration.WithLabelValues(method, endpoint).Observe(elapsed.Seconds())
+}
+
+// IncTelemostRetry records a retried Telemost API request
+func (m *Metrics) IncTelemostRetry(method, endpoint string) {
+	if m == nil {
+		return
+	}
+	m.telemostRetries.WithLabelValues(method, endpoint).Inc()
+}
+
+// IncOAuthFlow records an OAuth flow being started, completed or failed
+func (m *Metrics) IncOAuthFlow(result string) {
+	if m == nil {
+		return
+	}
+	m.oauthFlows.WithLabelValues(result).Inc()
+}
+
+// IncCommand records a slash command invocation
+func (m *Metrics) IncCommand(subcommand string) {
+	if m == nil {
+		return
+	}
+	m.commands.WithLabelValues(subcommand).Inc()
+}
+
+// ObserveJobRun records a run of a background job
+func (m *Metrics) ObserveJobRun(job string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.jobRuns.WithLabelValues(job).Inc()
+	m.jobDuration.WithLabelValues(job).Observe(elapsed.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{})
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounters(t *testing.T) {
+	m := New()
+	m.IncCommand("start")
+	m.IncCommand("start")
+	m.IncCommand("help")
+	m.IncOAuthFlow("completed")
+
+	expected := `
+# HELP telemost_command_invocations_total Slash command invocations by subcommand.
+# TYPE telemost_command_invocations_total counter
+telemost_command_invocations_total{subcommand="help"} 1
+telemost_command_invocations_total{subcommand="start"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(m.commands, strings.NewReader(expected)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.oauthFlows.WithLabelValues("completed")))
+}
+
+func TestHistogram(t *testing.T) {
+	m := New()
+	m.ObserveJobRun("background", 300*time.Millisecond)
+
+	expected := `
+# HELP telemost_job_duration_seconds Duration of background job runs.
+# TYPE telemost_job_duration_seconds histogram
+telemost_job_duration_seconds_bucket{job="background",le="0.05"} 0
+telemost_job_duration_seconds_bucket{job="background",le="0.1"} 0
+telemost_job_duration_seconds_bucket{job="background",le="0.25"} 0
+telemost_job_duration_seconds_bucket{job="background",le="0.5"} 1
+telemost_job_duration_seconds_bucket{job="background",le="1"} 1
+telemost_job_duration_seconds_bucket{job="background",le="2.5"} 1
+telemost_job_duration_seconds_bucket{job="background",le="5"} 1
+telemost_job_duration_seconds_bucket{job="background",le="10"} 1
+telemost_job_duration_seconds_bucket{job="background",le="30"} 1
+telemost_job_duration_seconds_bucket{job="background",le="+Inf"} 1
+telemost_job_duration_seconds_sum{job="background"} 0.3
+telemost_job_duration_seconds_count{job="background"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(m.jobDuration, strings.NewReader(expected)))
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveTelemostRequest("POST", "/conferences", "201", time.Second)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `telemost_api_requests_total{endpoint="/conferences",method="POST",status="201"} 1`)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.IncCommand("start")
+	m.ObserveJobRun("background", time.Second)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
-	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
 )
 
 const (
 	oauthStateKeyPrefix = "telemost_oauth_state_"
 	userTokenKeyPrefix  = "telemost_user_token_"
 	oauthRedirectURL    = "/plugins/com.mattermost.plugin-telemost/oauth/callback"
+	oauthStartPath      = "/plugins/com.mattermost.plugin-telemost/oauth/start"
+	yandexOAuthURL      = "https://oauth.yandex.ru"
 )
 
 // OAuthState represents the OAuth state for security
@@ -30,6 +34,13 @@ type UserToken struct {
 	AccessToken string    `json:"access_token"`
 	ExpiresAt   time.Time `json:"expires_at"`
 	UserID      string    `json:"user_id"`
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+
+	// RefreshToken is only issued when the plugin uses the authorization code flow
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// ExpiryNotifiedAt is when the user was last reminded that the token expires soon
+	ExpiryNotifiedAt time.Time `json:"expiry_notified_at,omitempty"`
 }
 
 // OAuthError represents an OAuth error response
@@ -86,27 +97,41 @@ func (p *Plugin) handleOAuthStart(w http.ResponseWriter, r *http.Request) {
 
 	// Build OAuth URL
 	config := p.getConfiguration()
-	redirectURI := config.SiteURL + oauthRedirectURL
+	redirectURI := p.getSiteURL() + oauthRedirectURL
+	// With a client secret the server can exchange an authorization code for a refresh token;
+	// without one Yandex returns the access token to the browser in the URL fragment
+	responseType := "token"
+	if config.YandexClientSecret != "" {
+		responseType = "code"
+	}
 	oauthURL := fmt.Sprintf(
-		"https://oauth.yandex.ru/authorize?response_type=token&client_id=%s&redirect_uri=%s&state=%s",
+		"%s/authorize?response_type=%s&client_id=%s&redirect_uri=%s&state=%s",
+		yandexOAuthURL,
+		responseType,
 		config.YandexClientID,
 		url.QueryEscape(redirectURI),
 		state,
 	)
 
+	p.metrics.IncOAuthFlow("started")
+
 	// Redirect to Yandex OAuth
 	http.Redirect(w, r, oauthURL, http.StatusTemporaryRedirect)
 }
 
 // handleOAuthCallback handles the OAuth callback from Yandex
 func (p *Plugin) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	// The authorization code flow sends the code as a query parameter
+	if code := r.URL.Query().Get("code"); code != "" {
+		p.handleOAuthCode(w, r, code)
+		return
+	}
+
 	// For Yandex OAuth, we need to handle this differently since it uses URL fragments
 	// The actual OAuth response will be in the fragment, but we can't access it server-side
 	// Instead, we'll create a simple HTML page that extracts the token from the fragment
 
-	// Get configuration to use proper Site URL
-	config := p.getConfiguration()
-	siteURL := config.SiteURL
+	siteURL := p.getSiteURL()
 
 	// Create HTML page that will extract the token from the URL fragment
 	html := fmt.Sprintf(`
@@ -122,6 +147,7 @@ func (p *Plugin) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
         const params = new URLSearchParams(fragment);
         
         const accessToken = params.get('access_token');
+        const expiresIn = params.get('expires_in');
         const error = params.get('error');
         const state = params.get('state');
         
@@ -137,6 +163,7 @@ func (p *Plugin) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
                 },
                 body: JSON.stringify({
                     access_token: accessToken,
+                    expires_in: expiresIn,
                     state: state
                 })
             }).then(response => {
@@ -168,6 +195,7 @@ func (p *Plugin) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
 // OAuthCompleteRequest represents the request to complete OAuth
 type OAuthCompleteRequest struct {
 	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
 	State       string `json:"state"`
 }
 
@@ -185,70 +213,134 @@ func (p *Plugin) handleOAuthComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Retrieve and validate OAuth state
-	stateJSON, appErr := p.API.KVGet(oauthStateKeyPrefix + req.State)
-	if appErr != nil {
-		p.API.LogError("Failed to retrieve OAuth state", "error", appErr.Error())
+	oauthState, err := p.consumeOAuthState(req.State)
+	if err != nil {
+		p.metrics.IncOAuthFlow("failed")
 		http.Error(w, "Invalid or expired OAuth state", http.StatusBadRequest)
 		return
 	}
 
-	var oauthState OAuthState
-	if err := json.Unmarshal(stateJSON, &oauthState); err != nil {
-		p.API.LogError("Failed to unmarshal OAuth state", "error", err.Error())
-		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
-		return
+	// Store user token
+	userToken := &UserToken{
+		AccessToken: req.AccessToken,
+		ExpiresAt:   tokenExpiry(req.ExpiresIn),
+		UserID:      oauthState.UserID,
+		ConnectedAt: time.Now(),
 	}
 
-	// Check if state has expired
-	if time.Now().After(oauthState.ExpiresAt) {
-		p.API.LogError("OAuth state expired", "user_id", oauthState.UserID)
-		http.Error(w, "OAuth state expired", http.StatusBadRequest)
+	if err := p.completeConnection(oauthState, userToken); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Store user token
-	userToken := UserToken{
-		AccessToken: req.AccessToken,
-		ExpiresAt:   time.Now().Add(24 * time.Hour), // Assume 24 hour expiry
-		UserID:      oauthState.UserID,
+	// Return success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": "OAuth token stored successfully",
+	})
+}
+
+// handleOAuthCode completes the authorization code flow by exchanging the code for tokens
+func (p *Plugin) handleOAuthCode(w http.ResponseWriter, r *http.Request, code string) {
+	oauthState, err := p.consumeOAuthState(r.URL.Query().Get("state"))
+	if err != nil {
+		p.metrics.IncOAuthFlow("failed")
+		http.Error(w, "Invalid or expired OAuth state", http.StatusBadRequest)
+		return
 	}
 
-	tokenJSON, marshalErr := json.Marshal(userToken)
-	if marshalErr != nil {
-		p.API.LogError("Failed to marshal user token", "error", marshalErr.Error())
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	config := p.getConfiguration()
+	token, err := exchangeOAuthCode(config.YandexClientID, config.YandexClientSecret, code)
+	if err != nil {
+		p.API.LogError("Failed to exchange OAuth code", "error", err.Error())
+		p.recordAudit(audit.EventConnect, oauthState.UserID, oauthState.ChannelID, false, map[string]string{"error": err.Error()})
+		p.metrics.IncOAuthFlow("failed")
+		http.Error(w, "Failed to complete OAuth setup", http.StatusBadGateway)
 		return
 	}
 
-	storeAppErr := p.API.KVSet(userTokenKeyPrefix+oauthState.UserID, tokenJSON)
-	if storeAppErr != nil {
-		p.API.LogError("Failed to store user token", "error", storeAppErr.Error())
+	userToken := &UserToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.expiresAt(),
+		UserID:       oauthState.UserID,
+		ConnectedAt:  time.Now(),
+	}
+
+	if err := p.completeConnection(oauthState, userToken); err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	http.Redirect(w, r, p.getSiteURL(), http.StatusFound)
+}
+
+// consumeOAuthState loads and deletes the state of an OAuth flow, failing when it is unknown or
+// expired
+func (p *Plugin) consumeOAuthState(state string) (*OAuthState, error) {
+	stateJSON, appErr := p.API.KVGet(oauthStateKeyPrefix + state)
+	if appErr != nil {
+		p.API.LogError("Failed to retrieve OAuth state", "error", appErr.Error())
+		return nil, fmt.Errorf("unknown OAuth state")
+	}
+
+	var oauthState OAuthState
+	if err := json.Unmarshal(stateJSON, &oauthState); err != nil {
+		p.API.LogError("Failed to unmarshal OAuth state", "error", err.Error())
+		return nil, fmt.Errorf("invalid OAuth state")
+	}
+
 	// Clean up OAuth state
-	p.API.KVDelete(oauthStateKeyPrefix + req.State)
+	p.API.KVDelete(oauthStateKeyPrefix + state)
 
-	// Post success message to the channel
-	successPost := &model.Post{
-		ChannelId: oauthState.ChannelID,
-		Message:   "✅ **Connected to Telemost**\n\nYour Telemost authentication has been connected. Use `/telemost start` to create a meeting.",
-		UserId:    oauthState.UserID,
+	// Check if state has expired
+	if time.Now().After(oauthState.ExpiresAt) {
+		p.recordAudit(audit.EventConnect, oauthState.UserID, oauthState.ChannelID, false, map[string]string{"error": "OAuth state expired"})
+		return nil, fmt.Errorf("OAuth state expired")
 	}
 
-	if _, appErr := p.API.CreatePost(successPost); appErr != nil {
-		p.API.LogError("Failed to create success post", "error", appErr.Error())
+	return &oauthState, nil
+}
+
+// completeConnection stores the user's token and announces the connection
+func (p *Plugin) completeConnection(oauthState *OAuthState, userToken *UserToken) error {
+	if err := p.saveUserToken(userToken); err != nil {
+		p.API.LogError("Failed to store user token", "error", err.Error())
+		p.metrics.IncOAuthFlow("failed")
+		return err
 	}
 
-	// Return success response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "success",
-		"message": "OAuth token stored successfully",
-	})
+	p.recordAudit(audit.EventConnect, oauthState.UserID, oauthState.ChannelID, true, nil)
+	p.metrics.IncOAuthFlow("completed")
+	p.emitEvent(eventUserConnected, map[string]interface{}{"user_id": oauthState.UserID})
+
+	// Confirm privately instead of announcing it in the channel the flow started from
+	p.sendConnectionStatus(oauthState.UserID, connectionStatusConnected)
+
+	return nil
+}
+
+// saveUserToken stores a user's OAuth token
+func (p *Plugin) saveUserToken(userToken *UserToken) error {
+	tokenJSON, err := json.Marshal(userToken)
+	if err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVSet(userTokenKeyPrefix+userToken.UserID, tokenJSON); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// tokenExpiry converts the expires_in seconds Yandex returns, assuming 24 hours when missing
+func tokenExpiry(expiresIn string) time.Time {
+	if seconds, err := strconv.ParseInt(expiresIn, 10, 64); err == nil && seconds > 0 {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return time.Now().Add(24 * time.Hour)
 }
 
 // getUserToken retrieves a user's OAuth token
@@ -265,6 +357,10 @@ func (p *Plugin) getUserToken(userID string) (*UserToken, error) {
 
 	// Check if token has expired
 	if time.Now().After(userToken.ExpiresAt) {
+		if refreshed, err := p.refreshUserToken(&userToken); err == nil {
+			return refreshed, nil
+		}
+
 		// Delete expired token
 		p.API.KVDelete(userTokenKeyPrefix + userID)
 		return nil, fmt.Errorf("token expired")
@@ -273,8 +369,21 @@ func (p *Plugin) getUserToken(userID string) (*UserToken, error) {
 	return &userToken, nil
 }
 
-// isUserAuthenticated checks if a user has a valid OAuth token
-func (p *Plugin) isUserAuthenticated(userID string) bool {
-	_, err := p.getUserToken(userID)
-	return err == nil
+// DisconnectUser removes a user's OAuth token and revokes it with Yandex. The revocation is nil
+// when the user had no token.
+func (s *authService) DisconnectUser(userID string) (*command.TokenRevocation, error) {
+	revocation, err := s.p.removeUserToken(userID)
+	if err != nil {
+		s.p.recordAudit(audit.EventDisconnect, userID, "", false, map[string]string{"error": err.Error()})
+		return nil, err
+	}
+
+	var details map[string]string
+	if revocation != nil {
+		details = map[string]string{"token_revocation": revocation.Status}
+	}
+
+	s.p.recordAudit(audit.EventDisconnect, userID, "", true, details)
+	s.p.emitEvent(eventUserDisconnected, map[string]interface{}{"user_id": userID})
+	return revocation, nil
 }
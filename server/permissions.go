@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// meetingPermissions describes what a user may do when creating a meeting
+type meetingPermissions struct {
+	AllowLiveStream bool
+}
+
+// authorizeMeetingCreation is the single place deciding whether a user may create a meeting in
+// a channel. channelID may be empty for meetings not tied to a channel, and userID for meetings
+// integrations create, which only the channel restrictions apply to. It returns a
+// *command.PermissionError when the configured restrictions deny the creation.
+func (p *Plugin) authorizeMeetingCreation(userID, channelID string) (*meetingPermissions, error) {
+	var channel *model.Channel
+	if channelID != "" {
+		var appErr *model.AppError
+		channel, appErr = p.API.GetChannel(channelID)
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
+	return p.authorizeMeetingInChannel(userID, channel)
+}
+
+// authorizeMeetingInChannel applies the restrictions of authorizeMeetingCreation to a channel,
+// which may be nil or a channel the user is about to create.
+func (p *Plugin) authorizeMeetingInChannel(userID string, channel *model.Channel) (*meetingPermissions, error) {
+	config := p.getConfiguration()
+
+	if channel != nil {
+		if config.BlockPublicChannels && channel.Type == model.ChannelTypeOpen {
+			return nil, &command.PermissionError{Reason: "meetings cannot be created in public channels"}
+		}
+		if config.BlockSharedChannels && channel.IsShared() {
+			return nil, &command.PermissionError{Reason: "meetings cannot be created in shared channels"}
+		}
+	}
+
+	if allowedChannels := splitList(config.AllowedChannels); len(allowedChannels) > 0 {
+		if channel == nil || !(containsString(allowedChannels, channel.Id) || containsString(allowedChannels, strings.ToLower(channel.Name))) {
+			return nil, &command.PermissionError{Reason: "meetings can only be created in specific channels"}
+		}
+	}
+
+	if allowedTeams := splitList(config.AllowedTeams); len(allowedTeams) > 0 {
+		allowed, err := p.isInAllowedTeam(userID, channel, allowedTeams)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, &command.PermissionError{Reason: "meetings can only be created in specific teams"}
+		}
+	}
+
+	if userID == "" {
+		return &meetingPermissions{
+			AllowLiveStream: !config.RestrictLiveStreamToAdmins,
+		}, nil
+	}
+
+	if allowedRoles := splitList(config.AllowedRoles); len(allowedRoles) > 0 {
+		roles, err := p.userRoles(userID, channel)
+		if err != nil {
+			return nil, err
+		}
+		if !containsAny(allowedRoles, roles) {
+			return nil, &command.PermissionError{Reason: "your role is not allowed to create meetings"}
+		}
+	}
+
+	if allowedGroups := splitList(config.AllowedGroups); len(allowedGroups) > 0 {
+		groups, appErr := p.API.GetGroupsForUser(userID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		names := []string{}
+		for _, group := range groups {
+			names = append(names, group.Id)
+			if group.Name != nil {
+				names = append(names, strings.ToLower(*group.Name))
+			}
+		}
+		if !containsAny(allowedGroups, names) {
+			return nil, &command.PermissionError{Reason: "only members of specific groups can create meetings"}
+		}
+	}
+
+	return &meetingPermissions{
+		AllowLiveStream: p.canLiveStream(userID),
+	}, nil
+}
+
+// canLiveStream checks whether the user may start live streams
+func (p *Plugin) canLiveStream(userID string) bool {
+	return !p.getConfiguration().RestrictLiveStreamToAdmins || p.API.HasPermissionTo(userID, model.PermissionManageSystem)
+}
+
+// isInAllowedTeam checks the channel's team, or for DMs, GMs and channel-less meetings any team
+// of the user, against the allowed teams.
+func (p *Plugin) isInAllowedTeam(userID string, channel *model.Channel, allowedTeams []string) (bool, error) {
+	var teams []*model.Team
+	if channel != nil && channel.TeamId != "" {
+		team, appErr := p.API.GetTeam(channel.TeamId)
+		if appErr != nil {
+			return false, appErr
+		}
+		teams = []*model.Team{team}
+	} else {
+		var appErr *model.AppError
+		teams, appErr = p.API.GetTeamsForUser(userID)
+		if appErr != nil {
+			return false, appErr
+		}
+	}
+
+	for _, team := range teams {
+		if containsString(allowedTeams, team.Id) || containsString(allowedTeams, strings.ToLower(team.Name)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// userRoles returns the system roles of a user plus their team and channel roles for the channel.
+// A channel that doesn't exist yet is being created by the user, who becomes its admin.
+func (p *Plugin) userRoles(userID string, channel *model.Channel) ([]string, error) {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	roles := strings.Fields(user.Roles)
+
+	if channel != nil {
+		if channel.Id == "" {
+			roles = append(roles, model.ChannelUserRoleId, model.ChannelAdminRoleId)
+		} else if member, appErr := p.API.GetChannelMember(channel.Id, userID); appErr == nil {
+			roles = append(roles, strings.Fields(member.Roles)...)
+		}
+		if channel.TeamId != "" {
+			if member, appErr := p.API.GetTeamMember(channel.TeamId, userID); appErr == nil {
+				roles = append(roles, strings.Fields(member.Roles)...)
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+// splitList parses a comma separated setting into lower-cased, trimmed entries
+func splitList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// containsAny reports whether any of the values is in the allowed list
+func containsAny(allowed, values []string) bool {
+	for _, value := range values {
+		if containsString(allowed, strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
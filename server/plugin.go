@@ -1,12 +1,12 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
 	"sync"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
 	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/metrics"
 	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -31,8 +31,25 @@ type Plugin struct {
 	// telemostClient is the client used to interact with Telemost API.
 	telemostClient *TelemostClient
 
+	// botUserID is the user ID of the plugin's bot account.
+	botUserID string
+
 	backgroundJob *cluster.Job
 
+	// webhookDeliveries tracks the outgoing webhook deliveries in flight, so deactivation can
+	// wait for them.
+	webhookDeliveries sync.WaitGroup
+
+	// audit is the store for audit events of meeting and auth actions.
+	audit *audit.Store
+
+	// metrics collects the plugin's Prometheus metrics on this node.
+	metrics *metrics.Metrics
+
+	// meetings and auth are the services shared by the command and HTTP handlers.
+	meetings *meetingService
+	auth     *authService
+
 	// configurationLock synchronizes access to the configuration.
 	configurationLock sync.RWMutex
 
@@ -44,20 +61,32 @@ type Plugin struct {
 // OnActivate is invoked when the plugin is activated. If an error is returned, the plugin will be deactivated.
 func (p *Plugin) OnActivate() error {
 	p.client = pluginapi.NewClient(p.API, p.Driver)
+	p.audit = audit.NewStore(p.client)
+	p.metrics = metrics.New()
+	p.meetings = &meetingService{p: p}
+	p.auth = &authService{p: p}
 
 	// p.kvstore = kvstore.NewKVStore(p.client) // Commented out as NewKVStore doesn't exist
 
+	if err := p.ensureBot(); err != nil {
+		return err
+	}
+
 	// Register the telemost command
 	if err := command.RegisterCommand(p.client); err != nil {
 		return errors.Wrap(err, "failed to register command")
 	}
 
-	p.commandClient = command.NewCommandHandler(p.client, p)
+	if err := p.ensureMeetingIndexes(); err != nil {
+		p.API.LogError("Failed to index meeting records", "error", err.Error())
+	}
+
+	p.commandClient = command.NewCommandHandler(p.client, p.services(), p.botUserID, p.metrics)
 
 	// Initialize Telemost client if configuration is available
 	config := p.getConfiguration()
 	if config != nil && config.TelemostOAuthToken != "" {
-		p.telemostClient = NewTelemostClient(config.TelemostOAuthToken, p.API)
+		p.telemostClient = NewTelemostClient(config.TelemostOAuthToken, p.API, p.metrics)
 	}
 
 	job, err := cluster.Schedule(
@@ -82,6 +111,9 @@ func (p *Plugin) OnDeactivate() error {
 			p.API.LogError("Failed to close background job", "err", err)
 		}
 	}
+
+	// Deliveries time out on their own, failed ones are retried after the next activation
+	p.webhookDeliveries.Wait()
 	return nil
 }
 
@@ -96,62 +128,103 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 
 // See https://developers.mattermost.com/extend/plugins/server/reference/
 
-// GetUserTokenForCommand retrieves a user's OAuth token for command handler
-func (p *Plugin) GetUserTokenForCommand(userID string) (*command.UserToken, error) {
-	tokenJSON, appErr := p.API.KVGet(userTokenKeyPrefix + userID)
-	if appErr != nil {
-		return nil, appErr
+// createMeeting authorizes, rate limits, creates and audits a meeting on behalf of a user.
+// All entry points creating meetings go through here.
+func (p *Plugin) createMeeting(provider MeetingProvider, req *command.MeetingRequest) (*TelemostMeeting, error) {
+	permissions, err := p.authorizeMeetingCreation(req.UserID, req.ChannelID)
+	if err != nil {
+		p.recordAudit(audit.EventMeetingCreate, req.UserID, req.ChannelID, false, map[string]string{"error": err.Error(), "source": req.Source})
+		p.recordMeetingFailure(err)
+		return nil, err
 	}
 
-	var userToken UserToken
-	if err := json.Unmarshal(tokenJSON, &userToken); err != nil {
+	refundRateLimit, err := p.takeMeetingRateLimit(req.UserID, req.ChannelID)
+	if err != nil {
+		p.recordMeetingFailure(err)
 		return nil, err
 	}
 
-	// Check if token has expired
-	if time.Now().After(userToken.ExpiresAt) {
-		// Delete expired token
-		p.API.KVDelete(userTokenKeyPrefix + userID)
-		return nil, fmt.Errorf("token expired")
+	liveStream := req.LiveStream
+	if !provider.Capabilities().LiveStream {
+		if liveStream != "" && liveStream != command.LiveStreamOff {
+			err := &command.UnsupportedError{Provider: providerDisplayNames[provider.Name()], Feature: "live streams"}
+			p.recordAudit(audit.EventMeetingCreate, req.UserID, req.ChannelID, false, map[string]string{"error": err.Error(), "source": req.Source})
+			refundRateLimit()
+			return nil, err
+		}
+		liveStream = command.LiveStreamOff
 	}
 
-	// Convert to command.UserToken
-	return &command.UserToken{
-		AccessToken: userToken.AccessToken,
-		ExpiresAt:   userToken.ExpiresAt.Format(time.RFC3339),
-		UserID:      userToken.UserID,
-	}, nil
-}
-
-// CreateMeetingWithUserToken creates a meeting using user's OAuth token for command handler
-func (p *Plugin) CreateMeetingWithUserToken(token, title, description string) (*command.TelemostMeeting, error) {
-	// Create Telemost client with user's OAuth token
-	client := NewTelemostClient(token, p.API)
-
-	// Create the meeting
-	meeting, err := client.CreateMeetingWithDefaults(p.getConfiguration(), title, description, []string{})
+	config, err := liveStreamDefaults(p.getConfiguration(), permissions, liveStream)
 	if err != nil {
+		p.recordAudit(audit.EventMeetingCreate, req.UserID, req.ChannelID, false, map[string]string{"error": err.Error(), "source": req.Source})
+		p.recordMeetingFailure(err)
+		refundRateLimit()
 		return nil, err
 	}
-
-	// Convert to command.TelemostMeeting
-	result := &command.TelemostMeeting{
-		ID:      meeting.ID,
-		JoinURL: meeting.JoinURL,
-	}
-	if meeting.LiveStream != nil {
-		result.LiveStream = &struct {
-			WatchURL string `json:"watch_url"`
-		}{
-			WatchURL: meeting.LiveStream.WatchURL,
-		}
+	if req.WaitingRoomLevel != "" {
+		config = withWaitingRoom{config, req.WaitingRoomLevel}
 	}
 
-	return result, nil
+	meeting, err := provider.CreateMeetingWithDefaults(config, req.Title, req.Description, req.Cohosts)
+	if err != nil {
+		p.recordAudit(audit.EventMeetingCreate, req.UserID, req.ChannelID, false, map[string]string{"error": err.Error(), "source": req.Source})
+		p.recordMeetingFailure(err)
+		refundRateLimit()
+		return nil, err
+	}
+	p.recordAudit(audit.EventMeetingCreate, req.UserID, req.ChannelID, true, map[string]string{"meeting_id": meeting.ID, "source": req.Source, "provider": provider.Name()})
+	p.recordMeetingStats(req.UserID, req.ChannelID)
+	p.storeLastMeeting(req, provider.Name(), meeting)
+	p.storeMeetingRecord(req, provider.Name(), meeting)
+	p.announceLiveStream(req.UserID, req.ChannelID, req.Title, meeting)
+	p.emitEvent(eventMeetingCreated, map[string]interface{}{
+		"meeting_id": meeting.ID,
+		"join_url":   meeting.JoinURL,
+		"user_id":    req.UserID,
+		"channel_id": req.ChannelID,
+		"title":      req.Title,
+		"source":     req.Source,
+		"provider":   provider.Name(),
+	})
+
+	return meeting, nil
 }
 
 // runJob is a background job that runs periodically
 func (p *Plugin) runJob() {
-	// Include job logic here
 	p.API.LogInfo("Background job is currently running")
+
+	started := time.Now()
+	defer func() {
+		p.metrics.ObserveJobRun("background", time.Since(started))
+		p.recordJobStatus(started)
+	}()
+
+	p.expireAuditEvents()
+	p.expireStats()
+	p.endStaleMeetings()
+	p.refreshMeetingStates()
+	p.expireMeetingRecords()
+	p.retryWebhookDeliveries()
+	p.retryTokenRevocations()
+	p.retryDeactivations()
+	p.checkTokenExpiry()
+}
+
+// expireAuditEvents deletes audit events older than the configured retention
+func (p *Plugin) expireAuditEvents() {
+	retentionDays := p.getConfiguration().AuditRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+
+	deleted, err := p.audit.DeleteBefore(time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		p.API.LogError("Failed to expire audit events", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		p.API.LogInfo("Expired audit events", "count", deleted)
+	}
 }
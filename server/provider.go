@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	providerTelemost = "telemost"
+	providerJitsi    = "jitsi"
+)
+
+// providerDisplayNames are the provider names shown to users
+var providerDisplayNames = map[string]string{
+	providerTelemost: "Telemost",
+	providerJitsi:    "Jitsi",
+}
+
+// providerCapabilities describes the meeting features a provider supports
+type providerCapabilities struct {
+	Cohosts     bool
+	WaitingRoom bool
+	LiveStream  bool
+	End         bool
+}
+
+// MeetingProvider is a backend hosting meetings. Meetings of every provider are described by a
+// TelemostMeeting.
+type MeetingProvider interface {
+	// Name returns the provider's name as stored with meetings and shown to users
+	Name() string
+
+	// Capabilities returns the meeting features the provider supports
+	Capabilities() providerCapabilities
+
+	// CreateMeetingWithDefaults creates a meeting with the given defaults. Settings the provider
+	// doesn't support are ignored.
+	CreateMeetingWithDefaults(config meetingDefaults, title string, description string, cohosts []string) (*TelemostMeeting, error)
+
+	// GetMeeting returns a meeting
+	GetMeeting(meetingID string) (*TelemostMeeting, error)
+
+	// UpdateLiveStream starts a live stream with the given access level, or stops it when the
+	// access level is empty
+	UpdateLiveStream(meetingID, accessLevel string) (*TelemostMeeting, error)
+
+	// EndMeeting ends a meeting for all participants
+	EndMeeting(meetingID string) error
+}
+
+// meetingProvider returns the provider hosting meetings in a channel, acting as the user
+func (p *Plugin) meetingProvider(userID, channelID string) (MeetingProvider, error) {
+	return p.namedMeetingProvider(p.channelProviderName(channelID), userID)
+}
+
+// namedMeetingProvider returns the provider with the given name, acting as the user. Telemost
+// requires the user to be connected.
+func (p *Plugin) namedMeetingProvider(name, userID string) (MeetingProvider, error) {
+	if name == providerJitsi {
+		return newJitsiProvider(p.getConfiguration().JitsiURL), nil
+	}
+
+	userToken, err := p.getUserToken(userID)
+	if err != nil {
+		return nil, &command.NotConnectedError{}
+	}
+	return NewTelemostClient(userToken.AccessToken, p.API, p.metrics), nil
+}
+
+// existingMeetingProvider returns the provider hosting a meeting: the one recorded for the meeting
+// or the user's last meeting, or the provider of the channel for other meetings
+func (p *Plugin) existingMeetingProvider(userID, channelID, meetingID string) (MeetingProvider, error) {
+	if record, err := p.getMeetingRecord(meetingID); err == nil && record != nil && record.Provider != "" {
+		return p.namedMeetingProvider(record.Provider, userID)
+	}
+	if lastMeeting, err := p.getLastMeeting(userID); err == nil && lastMeeting != nil && lastMeeting.ID == meetingID && lastMeeting.Provider != "" {
+		return p.namedMeetingProvider(lastMeeting.Provider, userID)
+	}
+	return p.meetingProvider(userID, channelID)
+}
+
+// namedProviderCapabilities returns the features supported by the provider with the given name
+func namedProviderCapabilities(name string) providerCapabilities {
+	if name == providerJitsi {
+		return (&jitsiProvider{}).Capabilities()
+	}
+	return (&TelemostClient{}).Capabilities()
+}
+
+// serviceMeetingProvider returns the provider hosting meetings in a channel, acting as the
+// service account configured with the legacy Telemost OAuth token
+func (p *Plugin) serviceMeetingProvider(channelID string) (MeetingProvider, error) {
+	if p.channelProviderName(channelID) == providerJitsi {
+		return newJitsiProvider(p.getConfiguration().JitsiURL), nil
+	}
+
+	if p.telemostClient == nil {
+		return nil, &command.NotConnectedError{}
+	}
+	return p.telemostClient, nil
+}
+
+// channelProviderName picks the provider for a channel: Jitsi when a Jitsi server is configured
+// and the channel or its team is listed in the Jitsi settings, Telemost otherwise
+func (p *Plugin) channelProviderName(channelID string) string {
+	config := p.getConfiguration()
+	if config.JitsiURL == "" || channelID == "" {
+		return providerTelemost
+	}
+
+	if len(splitList(config.JitsiChannels)) == 0 && len(splitList(config.JitsiTeams)) == 0 {
+		return providerTelemost
+	}
+
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return providerTelemost
+	}
+	return p.channelProvider(channel)
+}
+
+// channelProvider picks the provider for a channel, which may not have been created yet
+func (p *Plugin) channelProvider(channel *model.Channel) string {
+	config := p.getConfiguration()
+	if config.JitsiURL == "" {
+		return providerTelemost
+	}
+
+	jitsiChannels := splitList(config.JitsiChannels)
+	jitsiTeams := splitList(config.JitsiTeams)
+	if containsString(jitsiChannels, channel.Id) || containsString(jitsiChannels, strings.ToLower(channel.Name)) {
+		return providerJitsi
+	}
+
+	if channel.TeamId == "" || len(jitsiTeams) == 0 {
+		return providerTelemost
+	}
+	team, appErr := p.API.GetTeam(channel.TeamId)
+	if appErr != nil {
+		return providerTelemost
+	}
+	if containsString(jitsiTeams, team.Id) || containsString(jitsiTeams, strings.ToLower(team.Name)) {
+		return providerJitsi
+	}
+	return providerTelemost
+}
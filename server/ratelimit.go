@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	rateLimitKeyPrefix = "telemost_ratelimit_"
+	rateLimitWindow    = time.Hour
+	rateLimitRetries   = 5
+)
+
+// tokenBucket is the KV representation of a rate limit bucket. Buckets start full, hold at
+// most limit tokens and refill at limit tokens per rateLimitWindow. A bucket that hasn't changed
+// for rateLimitWindow is full again, so buckets expire after that and a missing bucket is full.
+type tokenBucket struct {
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// rateLimitBucket names a bucket and its limit
+type rateLimitBucket struct {
+	scope string
+	key   string
+	limit int
+}
+
+// takeMeetingRateLimit takes one meeting creation from the user's and the channel's buckets. It
+// returns a *command.RateLimitError when either of them is exhausted, without using up the
+// other. The returned refund gives the creation back when the meeting isn't created after all.
+func (p *Plugin) takeMeetingRateLimit(userID, channelID string) (refund func(), err error) {
+	config := p.getConfiguration()
+
+	buckets := []rateLimitBucket{}
+	if config.MeetingRateLimitPerUser > 0 && userID != "" {
+		buckets = append(buckets, rateLimitBucket{"user", rateLimitKeyPrefix + "user_" + userID, config.MeetingRateLimitPerUser})
+	}
+	if config.MeetingRateLimitPerChannel > 0 && channelID != "" {
+		buckets = append(buckets, rateLimitBucket{"channel", rateLimitKeyPrefix + "channel_" + channelID, config.MeetingRateLimitPerChannel})
+	}
+
+	taken := []rateLimitBucket{}
+	refund = func() {
+		for _, bucket := range taken {
+			if err := p.refundToken(bucket.key, bucket.limit); err != nil {
+				p.API.LogWarn("Failed to refund rate limit", "key", bucket.key, "error", err.Error())
+			}
+		}
+	}
+
+	for _, bucket := range buckets {
+		if err := p.takeToken(bucket.key, bucket.limit); err != nil {
+			refund()
+			if rateErr, ok := err.(*command.RateLimitError); ok {
+				rateErr.Scope = bucket.scope
+				p.recordAudit(audit.EventRateLimited, userID, channelID, false, map[string]string{"scope": bucket.scope, "limit": strconv.Itoa(bucket.limit)})
+			}
+			return nil, err
+		}
+		taken = append(taken, bucket)
+	}
+
+	return refund, nil
+}
+
+// takeToken atomically refills the bucket stored under key and takes one token from it. The
+// compare-and-set keeps the bucket consistent across all servers of a cluster.
+func (p *Plugin) takeToken(key string, limit int) error {
+	var retryAfter time.Duration
+	allowed := false
+
+	err := p.updateBucket(key, limit, func(bucket *tokenBucket, refillRate float64) {
+		allowed = bucket.Tokens >= 1
+		if allowed {
+			bucket.Tokens--
+		} else {
+			retryAfter = time.Duration((1 - bucket.Tokens) / refillRate * float64(time.Second))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	if !allowed {
+		return &command.RateLimitError{Limit: limit, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// refundToken gives a taken token back to the bucket stored under key
+func (p *Plugin) refundToken(key string, limit int) error {
+	return p.updateBucket(key, limit, func(bucket *tokenBucket, _ float64) {
+		bucket.Tokens = math.Min(float64(limit), bucket.Tokens+1)
+	})
+}
+
+// updateBucket refills the bucket stored under key and applies update to it with compare-and-set,
+// retrying on conflicts. The bucket expires once it would be full again.
+func (p *Plugin) updateBucket(key string, limit int, update func(bucket *tokenBucket, refillRate float64)) error {
+	refillRate := float64(limit) / rateLimitWindow.Seconds()
+
+	for i := 0; i < rateLimitRetries; i++ {
+		var oldValue []byte
+		if err := p.client.KV.Get(key, &oldValue); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		bucket := tokenBucket{Tokens: float64(limit), UpdatedAt: now}
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, &bucket); err != nil {
+				return err
+			}
+		}
+		bucket.Tokens = math.Min(float64(limit), bucket.Tokens+now.Sub(bucket.UpdatedAt).Seconds()*refillRate)
+		bucket.UpdatedAt = now
+		update(&bucket, refillRate)
+
+		saved, err := p.client.KV.Set(key, bucket, pluginapi.SetAtomic(oldValue), pluginapi.SetExpiry(rateLimitWindow))
+		if err != nil {
+			return err
+		}
+		if saved {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to update bucket after %d retries", rateLimitRetries)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testBucketKey = rateLimitKeyPrefix + "user_userid"
+
+func newTestPlugin(api *plugintest.API) *Plugin {
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+	return p
+}
+
+// mockBucket serves the stored bucket, nil for a missing one, and records the buckets saved with
+// compare-and-set. Each saved value in results tells whether one attempt wins the compare-and-set.
+func mockBucket(t *testing.T, api *plugintest.API, stored *tokenBucket, results ...bool) *[]tokenBucket {
+	var value []byte
+	if stored != nil {
+		var err error
+		value, err = json.Marshal(stored)
+		require.NoError(t, err)
+	}
+	api.On("KVGet", testBucketKey).Return(value, nil)
+
+	saved := []tokenBucket{}
+	for _, result := range results {
+		api.On("KVSetWithOptions", testBucketKey, mock.Anything, mock.MatchedBy(func(opts model.PluginKVSetOptions) bool {
+			return opts.Atomic && opts.ExpireInSeconds == int64(rateLimitWindow.Seconds())
+		})).Return(result, nil).Once().Run(func(args mock.Arguments) {
+			var bucket tokenBucket
+			require.NoError(t, json.Unmarshal(args.Get(1).([]byte), &bucket))
+			saved = append(saved, bucket)
+		})
+	}
+	return &saved
+}
+
+func TestTakeToken(t *testing.T) {
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		stored         *tokenBucket
+		expectedTokens float64
+		retryAfter     time.Duration
+	}{
+		"missing bucket is full": {
+			expectedTokens: 9,
+		},
+		"refills over time": {
+			stored:         &tokenBucket{Tokens: 0, UpdatedAt: now.Add(-30 * time.Minute)},
+			expectedTokens: 4,
+		},
+		"refill is capped at the limit": {
+			stored:         &tokenBucket{Tokens: 8, UpdatedAt: now.Add(-3 * time.Hour)},
+			expectedTokens: 9,
+		},
+		"empty bucket is rate limited": {
+			stored:         &tokenBucket{Tokens: 0, UpdatedAt: now},
+			expectedTokens: 0,
+			retryAfter:     6 * time.Minute,
+		},
+		"partial token is rate limited until it refills": {
+			stored:         &tokenBucket{Tokens: 0.5, UpdatedAt: now},
+			expectedTokens: 0.5,
+			retryAfter:     3 * time.Minute,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			saved := mockBucket(t, api, tc.stored, true)
+
+			err := newTestPlugin(api).takeToken(testBucketKey, 10)
+			if tc.retryAfter > 0 {
+				var rateErr *command.RateLimitError
+				require.ErrorAs(t, err, &rateErr)
+				assert.Equal(t, 10, rateErr.Limit)
+				assert.InDelta(t, tc.retryAfter.Seconds(), rateErr.RetryAfter.Seconds(), 1)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, *saved, 1)
+			assert.InDelta(t, tc.expectedTokens, (*saved)[0].Tokens, 0.01)
+			assert.WithinDuration(t, time.Now(), (*saved)[0].UpdatedAt, time.Second)
+		})
+	}
+}
+
+func TestRefundToken(t *testing.T) {
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		stored         *tokenBucket
+		expectedTokens float64
+	}{
+		"gives the token back": {
+			stored:         &tokenBucket{Tokens: 3, UpdatedAt: now},
+			expectedTokens: 4,
+		},
+		"is capped at the limit": {
+			stored:         &tokenBucket{Tokens: 9.5, UpdatedAt: now},
+			expectedTokens: 10,
+		},
+		"missing bucket stays full": {
+			expectedTokens: 10,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			saved := mockBucket(t, api, tc.stored, true)
+
+			require.NoError(t, newTestPlugin(api).refundToken(testBucketKey, 10))
+			require.Len(t, *saved, 1)
+			assert.InDelta(t, tc.expectedTokens, (*saved)[0].Tokens, 0.01)
+		})
+	}
+}
+
+func TestUpdateBucketRetries(t *testing.T) {
+	for name, tc := range map[string]struct {
+		results     []bool
+		expectedErr string
+	}{
+		"saves on the first attempt": {
+			results: []bool{true},
+		},
+		"retries after a conflict": {
+			results: []bool{false, false, true},
+		},
+		"gives up after the retries": {
+			results:     []bool{false, false, false, false, false},
+			expectedErr: "failed to update bucket after 5 retries",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			defer api.AssertExpectations(t)
+			saved := mockBucket(t, api, &tokenBucket{Tokens: 5, UpdatedAt: time.Now()}, tc.results...)
+
+			err := newTestPlugin(api).takeToken(testBucketKey, 10)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Len(t, *saved, len(tc.results))
+			api.AssertNumberOfCalls(t, "KVGet", len(tc.results))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// meetingService implements command.MeetingService for the command and HTTP handlers
+type meetingService struct {
+	p *Plugin
+}
+
+// authService implements command.AuthService for the command and HTTP handlers
+type authService struct {
+	p *Plugin
+}
+
+// adminService implements command.AdminService for the command and HTTP handlers
+type adminService struct {
+	p *Plugin
+}
+
+// services returns the plugin's services for the command handler
+func (p *Plugin) services() command.Services {
+	return command.Services{
+		Meetings: p.meetings,
+		Auth:     p.auth,
+		Admin:    &adminService{p: p},
+		Audit:    p.audit,
+	}
+}
+
+// CreateMeeting creates a meeting with the channel's meeting provider
+func (s *meetingService) CreateMeeting(req *command.MeetingRequest) (*command.TelemostMeeting, error) {
+	provider, err := s.p.meetingProvider(req.UserID, req.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.p.createMeeting(provider, req)
+}
+
+// EndMeeting ends a meeting for all participants
+func (s *meetingService) EndMeeting(userID, channelID, meetingID string) error {
+	provider, err := s.p.existingMeetingProvider(userID, channelID, meetingID)
+	if err != nil {
+		return err
+	}
+
+	return s.p.endMeeting(provider, userID, channelID, meetingID, "user")
+}
+
+// GetLastMeeting returns the latest meeting a user created, or nil
+func (s *meetingService) GetLastMeeting(userID string) (*command.LastMeeting, error) {
+	return s.p.getLastMeeting(userID)
+}
+
+// GetIncidentChannelNameTemplate returns the configured incident channel name template
+func (s *meetingService) GetIncidentChannelNameTemplate() string {
+	return s.p.getConfiguration().GetIncidentChannelNameTemplate()
+}
+
+// CheckMeetingCreation checks that the user may create a meeting in a channel that doesn't exist
+// yet and can use its meeting provider. It returns the errors CreateMeeting would return for the
+// restrictions and the connection.
+func (s *authService) CheckMeetingCreation(userID string, channel *model.Channel) error {
+	if _, err := s.p.authorizeMeetingInChannel(userID, channel); err != nil {
+		return err
+	}
+	_, err := s.p.namedMeetingProvider(s.p.channelProvider(channel), userID)
+	return err
+}
+
+// IsConnected checks whether the user has a valid Telemost token, refreshing an expired one
+func (s *authService) IsConnected(userID string) bool {
+	_, err := s.p.getUserToken(userID)
+	return err == nil
+}
+
+// GetConnectURL returns the link starting the OAuth flow, which reports back to the channel
+func (s *authService) GetConnectURL(channelID string) string {
+	return s.p.connectURL(channelID)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	statsKeyPrefix        = "telemost_stats_"
+	statsTotalKey         = statsKeyPrefix + "total"
+	statsDayPrefix        = statsKeyPrefix + "day_"
+	statsTeamPrefix       = statsKeyPrefix + "team_"
+	statsChannelPrefix    = statsKeyPrefix + "channel_"
+	statsOrganizerPrefix  = statsKeyPrefix + "organizer_"
+	statsFailurePrefix    = statsKeyPrefix + "failure_"
+	statsDefaultDays      = 30
+	statsRetentionDays    = 365
+	statsTopOrganizers    = 10
+	statsDirectMessageKey = "direct"
+)
+
+// statsResponse is returned by GET /api/v1/stats
+type statsResponse struct {
+	ConnectedUsers         int              `json:"connected_users"`
+	TotalMeetings          int64            `json:"total_meetings"`
+	Organizers             int              `json:"organizers"`
+	AverageMeetingsPerUser float64          `json:"average_meetings_per_user"`
+	MeetingsPerDay         map[string]int64 `json:"meetings_per_day"`
+	MeetingsPerTeam        map[string]int64 `json:"meetings_per_team"`
+	MeetingsPerChannel     map[string]int64 `json:"meetings_per_channel"`
+	TopOrganizers          []organizerStat  `json:"top_organizers"`
+	FailuresByType         map[string]int64 `json:"failures_by_type"`
+}
+
+type organizerStat struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Meetings int64  `json:"meetings"`
+}
+
+// recordMeetingStats increments the usage counters for a created meeting
+func (p *Plugin) recordMeetingStats(userID, channelID string) {
+	keys := []string{statsTotalKey, statsDayPrefix + time.Now().UTC().Format("2006-01-02")}
+
+	if channelID != "" {
+		keys = append(keys, statsChannelPrefix+channelID)
+
+		teamID := statsDirectMessageKey
+		if channel, appErr := p.API.GetChannel(channelID); appErr == nil && channel.TeamId != "" {
+			teamID = channel.TeamId
+		}
+		keys = append(keys, statsTeamPrefix+teamID)
+	}
+	if userID != "" {
+		keys = append(keys, statsOrganizerPrefix+userID)
+	}
+
+	for _, key := range keys {
+		p.incrementCounter(key)
+	}
+}
+
+// recordMeetingFailure increments the failure counter for the type of the error
+func (p *Plugin) recordMeetingFailure(err error) {
+	p.incrementCounter(statsFailurePrefix + meetingFailureType(err))
+}
+
+// meetingFailureType classifies meeting creation errors for the statistics
+func meetingFailureType(err error) string {
+	var rateErr *command.RateLimitError
+	var permissionErr *command.PermissionError
+	var apiErr *TelemostAPIError
+	switch {
+	case errors.As(err, &rateErr):
+		return "rate_limited"
+	case errors.As(err, &permissionErr):
+		return "permission_denied"
+	case errors.As(err, &apiErr):
+		if apiErr.Code != "" {
+			return "telemost_" + strings.ToLower(apiErr.Code)
+		}
+		return "telemost_http_" + strconv.Itoa(apiErr.StatusCode)
+	default:
+		return "other"
+	}
+}
+
+// incrementCounter atomically adds one to a counter stored in KV
+func (p *Plugin) incrementCounter(key string) {
+	err := p.client.KV.SetAtomicWithRetries(key, func(oldValue []byte) (interface{}, error) {
+		var count int64
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, &count); err != nil {
+				return nil, err
+			}
+		}
+		return count + 1, nil
+	})
+	if err != nil {
+		p.API.LogError("Failed to increment statistics counter", "key", key, "error", err.Error())
+	}
+}
+
+// expireStats deletes the per-day counters older than statsRetentionDays and the per-channel and
+// per-organizer counters of channels and users that no longer exist
+func (p *Plugin) expireStats() {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, statsKeyPrefix)
+	if err != nil {
+		p.API.LogError("Failed to list statistics counters", "error", err.Error())
+		return
+	}
+
+	firstDay := time.Now().UTC().AddDate(0, 0, -statsRetentionDays+1).Format("2006-01-02")
+	deleted := 0
+	for _, key := range keys {
+		var expired bool
+		switch {
+		case strings.HasPrefix(key, statsDayPrefix):
+			expired = strings.TrimPrefix(key, statsDayPrefix) < firstDay
+		case strings.HasPrefix(key, statsChannelPrefix):
+			_, appErr := p.API.GetChannel(strings.TrimPrefix(key, statsChannelPrefix))
+			expired = appErr != nil && appErr.StatusCode == http.StatusNotFound
+		case strings.HasPrefix(key, statsOrganizerPrefix):
+			_, appErr := p.API.GetUser(strings.TrimPrefix(key, statsOrganizerPrefix))
+			expired = appErr != nil && appErr.StatusCode == http.StatusNotFound
+		}
+		if !expired {
+			continue
+		}
+
+		if err := p.client.KV.Delete(key); err != nil {
+			p.API.LogError("Failed to delete statistics counter", "key", key, "error", err.Error())
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		p.API.LogInfo("Expired statistics counters", "count", deleted)
+	}
+}
+
+// handleStats returns usage statistics to system admins
+func (p *Plugin) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" || !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	days := statsDefaultDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	stats, err := p.collectStats(days)
+	if err != nil {
+		p.API.LogError("Failed to collect statistics", "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// collectStats aggregates the KV counters. Meetings per day are limited to the last days days.
+func (p *Plugin) collectStats(days int) (*statsResponse, error) {
+	stats := &statsResponse{
+		MeetingsPerDay:     map[string]int64{},
+		MeetingsPerTeam:    map[string]int64{},
+		MeetingsPerChannel: map[string]int64{},
+		TopOrganizers:      []organizerStat{},
+		FailuresByType:     map[string]int64{},
+	}
+
+	connected, err := p.countConnectedUsers()
+	if err != nil {
+		return nil, err
+	}
+	stats.ConnectedUsers = connected
+
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, statsKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	firstDay := time.Now().UTC().AddDate(0, 0, -days+1).Format("2006-01-02")
+	organizers := []organizerStat{}
+	for _, key := range keys {
+		var count int64
+		if err := p.client.KV.Get(key, &count); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key == statsTotalKey:
+			stats.TotalMeetings = count
+		case strings.HasPrefix(key, statsDayPrefix):
+			if day := strings.TrimPrefix(key, statsDayPrefix); day >= firstDay {
+				stats.MeetingsPerDay[day] = count
+			}
+		case strings.HasPrefix(key, statsTeamPrefix):
+			stats.MeetingsPerTeam[p.teamDisplayName(strings.TrimPrefix(key, statsTeamPrefix))] += count
+		case strings.HasPrefix(key, statsChannelPrefix):
+			stats.MeetingsPerChannel[strings.TrimPrefix(key, statsChannelPrefix)] = count
+		case strings.HasPrefix(key, statsOrganizerPrefix):
+			organizers = append(organizers, organizerStat{UserID: strings.TrimPrefix(key, statsOrganizerPrefix), Meetings: count})
+		case strings.HasPrefix(key, statsFailurePrefix):
+			stats.FailuresByType[strings.TrimPrefix(key, statsFailurePrefix)] = count
+		}
+	}
+
+	stats.Organizers = len(organizers)
+	if stats.Organizers > 0 {
+		var organized int64
+		for _, organizer := range organizers {
+			organized += organizer.Meetings
+		}
+		stats.AverageMeetingsPerUser = float64(organized) / float64(stats.Organizers)
+	}
+
+	sort.Slice(organizers, func(i, j int) bool {
+		return organizers[i].Meetings > organizers[j].Meetings
+	})
+	if len(organizers) > statsTopOrganizers {
+		organizers = organizers[:statsTopOrganizers]
+	}
+	for i := range organizers {
+		if user, appErr := p.API.GetUser(organizers[i].UserID); appErr == nil {
+			organizers[i].Username = user.Username
+		}
+	}
+	stats.TopOrganizers = organizers
+
+	return stats, nil
+}
+
+// countConnectedUsers counts the users with a stored OAuth token
+func (p *Plugin) countConnectedUsers() (int, error) {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, userTokenKeyPrefix)
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// teamDisplayName resolves a team ID to its name for the statistics
+func (p *Plugin) teamDisplayName(teamID string) string {
+	if teamID == statsDirectMessageKey {
+		return teamID
+	}
+	if team, appErr := p.API.GetTeam(teamID); appErr == nil {
+		return team.Name
+	}
+	return teamID
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+)
+
+const lastMeetingKeyPrefix = "telemost_last_meeting_"
+
+// storeLastMeeting remembers the latest meeting a user created for /telemost status
+func (p *Plugin) storeLastMeeting(req *command.MeetingRequest, provider string, meeting *TelemostMeeting) {
+	if req.UserID == "" {
+		return
+	}
+
+	lastMeeting := command.LastMeeting{
+		ID:        meeting.ID,
+		JoinURL:   meeting.JoinURL,
+		Title:     req.Title,
+		ChannelID: req.ChannelID,
+		Provider:  provider,
+		CreatedAt: time.Now(),
+	}
+	if _, err := p.client.KV.Set(lastMeetingKeyPrefix+req.UserID, lastMeeting); err != nil {
+		p.API.LogError("Failed to store last meeting", "user_id", req.UserID, "error", err.Error())
+	}
+}
+
+// getLastMeeting returns the latest meeting a user created, or nil
+func (p *Plugin) getLastMeeting(userID string) (*command.LastMeeting, error) {
+	var lastMeeting command.LastMeeting
+	if err := p.client.KV.Get(lastMeetingKeyPrefix+userID, &lastMeeting); err != nil {
+		return nil, err
+	}
+	if lastMeeting.ID == "" {
+		return nil, nil
+	}
+	return &lastMeeting, nil
+}
+
+// GetUserStatus describes a user's connection and meeting defaults
+func (s *authService) GetUserStatus(userID string) (*command.UserStatus, error) {
+	config := s.p.getConfiguration()
+	status := &command.UserStatus{
+		WaitingRoomLevel:      config.GetDefaultWaitingRoomLevel(),
+		LiveStream:            config.IsLiveStreamEnabled() && s.p.canLiveStream(userID),
+		LiveStreamAccessLevel: config.GetDefaultLiveStreamAccessLevel(),
+	}
+
+	lastMeeting, err := s.p.getLastMeeting(userID)
+	if err != nil {
+		return nil, err
+	}
+	status.LastMeeting = lastMeeting
+
+	userToken, err := s.p.getUserToken(userID)
+	if err != nil {
+		return status, nil
+	}
+	status.Connected = true
+	status.ConnectedAt = userToken.ConnectedAt
+	status.ExpiresAt = userToken.ExpiresAt
+
+	info, err := NewYandexClient(userToken.AccessToken).GetUserInfo()
+	if err != nil {
+		status.AccountError = err.Error()
+		return status, nil
+	}
+	status.Login = info.Login
+	status.Email = info.DefaultEmail
+	status.DisplayName = info.DisplayName
+
+	return status, nil
+}
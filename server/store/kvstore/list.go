@@ -0,0 +1,31 @@
+package kvstore
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// listPageSize is the number of keys fetched per KVList call
+const listPageSize = 1000
+
+// ListKeysWithPrefix pages through all keys of the plugin and returns those starting with prefix.
+func ListKeysWithPrefix(kv *pluginapi.KVService, prefix string) ([]string, error) {
+	result := []string{}
+	for page := 0; ; page++ {
+		keys, err := kv.ListKeys(page, listPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				result = append(result, key)
+			}
+		}
+
+		if len(keys) < listPageSize {
+			return result, nil
+		}
+	}
+}
@@ -6,23 +6,27 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/metrics"
 	"github.com/mattermost/mattermost/server/public/plugin"
 )
 
 const (
-	telemostAPIBaseURL = "https://cloud-api.yandex.net/v1/telemost-api"
+	telemostAPIBaseURL   = "https://cloud-api.yandex.net/v1/telemost-api"
+	telemostMaxRetries   = 2
+	telemostRetryBackoff = 500 * time.Millisecond
+
+	// tokenCheckConferenceID is a conference ID that never exists, read to validate tokens
+	tokenCheckConferenceID = "00000000000000000000"
 )
 
-// TelemostMeeting represents a Telemost meeting response
-type TelemostMeeting struct {
-	ID         string `json:"id"`
-	JoinURL    string `json:"join_url"`
-	LiveStream *struct {
-		WatchURL string `json:"watch_url"`
-	} `json:"live_stream,omitempty"`
-}
+// TelemostMeeting represents a Telemost meeting response. It is shared with the command package.
+type TelemostMeeting = command.TelemostMeeting
 
 // TelemostCreateRequest represents the request body for creating a meeting
 type TelemostCreateRequest struct {
@@ -37,6 +41,14 @@ type TelemostCreateRequest struct {
 	} `json:"cohosts,omitempty"`
 }
 
+// TelemostUpdateRequest represents the request body for updating a meeting. A nil live stream
+// stops the stream.
+type TelemostUpdateRequest struct {
+	LiveStream *struct {
+		AccessLevel string `json:"access_level,omitempty"`
+	} `json:"live_stream"`
+}
+
 // TelemostError represents an error response from the Telemost API
 type TelemostError struct {
 	Error       string `json:"error"`
@@ -47,58 +59,91 @@ type TelemostError struct {
 	} `json:"details,omitempty"`
 }
 
+// TelemostAPIError is returned when the Telemost API responds with an error
+type TelemostAPIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *TelemostAPIError) Error() string {
+	return fmt.Sprintf("telemost API error: %s - %s", e.Code, e.Message)
+}
+
 // TelemostClient handles API interactions with Telemost
 type TelemostClient struct {
 	oauthToken string
 	api        plugin.API
+	metrics    *metrics.Metrics
 }
 
 // NewTelemostClient creates a new Telemost API client
-func NewTelemostClient(oauthToken string, api plugin.API) *TelemostClient {
+func NewTelemostClient(oauthToken string, api plugin.API, metrics *metrics.Metrics) *TelemostClient {
 	return &TelemostClient{
 		oauthToken: oauthToken,
 		api:        api,
+		metrics:    metrics,
 	}
 }
 
 // CreateMeeting creates a new Telemost meeting
 func (tc *TelemostClient) CreateMeeting(req *TelemostCreateRequest) (*TelemostMeeting, error) {
-	url := fmt.Sprintf("%s/conferences", telemostAPIBaseURL)
-
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	statusCode, body, err := tc.do(http.MethodPost, "/conferences", jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	httpReq.Header.Set("Authorization", "OAuth "+tc.oauthToken)
-	httpReq.Header.Set("Content-Type", "application/json")
+	return decodeMeeting(statusCode, body, http.StatusCreated, "create meeting")
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// GetMeeting returns a Telemost meeting
+func (tc *TelemostClient) GetMeeting(meetingID string) (*TelemostMeeting, error) {
+	statusCode, body, err := tc.do(http.MethodGet, "/conferences/"+url.PathEscape(meetingID), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Do(httpReq)
+	return decodeMeeting(statusCode, body, http.StatusOK, "get meeting")
+}
+
+// UpdateLiveStream starts a live stream with the given access level, or stops it when the access
+// level is empty
+func (tc *TelemostClient) UpdateLiveStream(meetingID, accessLevel string) (*TelemostMeeting, error) {
+	req := &TelemostUpdateRequest{}
+	if accessLevel != "" {
+		req.LiveStream = &struct {
+			AccessLevel string `json:"access_level,omitempty"`
+		}{
+			AccessLevel: accessLevel,
+		}
+	}
+
+	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	statusCode, body, err := tc.do(http.MethodPatch, "/conferences/"+url.PathEscape(meetingID), jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusCreated {
+	return decodeMeeting(statusCode, body, http.StatusOK, "update live stream")
+}
+
+// decodeMeeting parses a meeting from a response with the expected status, or the API error
+func decodeMeeting(statusCode int, body []byte, expectedStatus int, action string) (*TelemostMeeting, error) {
+	if statusCode != expectedStatus {
 		var telemostErr TelemostError
 		if err := json.Unmarshal(body, &telemostErr); err != nil {
-			return nil, fmt.Errorf("failed to create meeting, status: %d, body: %s", resp.StatusCode, string(body))
+			return nil, fmt.Errorf("failed to %s, status: %d, body: %s", action, statusCode, string(body))
 		}
-		return nil, fmt.Errorf("telemost API error: %s - %s", telemostErr.Error, telemostErr.Message)
+		return nil, &TelemostAPIError{StatusCode: statusCode, Code: telemostErr.Error, Message: telemostErr.Message}
 	}
 
 	var meeting TelemostMeeting
@@ -109,12 +154,159 @@ func (tc *TelemostClient) CreateMeeting(req *TelemostCreateRequest) (*TelemostMe
 	return &meeting, nil
 }
 
-// CreateMeetingWithDefaults creates a meeting with default settings from configuration
-func (tc *TelemostClient) CreateMeetingWithDefaults(config interface {
+// Name returns "telemost"
+func (tc *TelemostClient) Name() string {
+	return providerTelemost
+}
+
+// Capabilities returns that Telemost supports all optional meeting features
+func (tc *TelemostClient) Capabilities() providerCapabilities {
+	return providerCapabilities{
+		Cohosts:     true,
+		WaitingRoom: true,
+		LiveStream:  true,
+		End:         true,
+	}
+}
+
+// EndMeeting deletes a Telemost meeting, disconnecting its participants
+func (tc *TelemostClient) EndMeeting(meetingID string) error {
+	statusCode, body, err := tc.do(http.MethodDelete, "/conferences/"+url.PathEscape(meetingID), nil)
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusNoContent || statusCode == http.StatusOK {
+		return nil
+	}
+
+	var telemostErr TelemostError
+	if err := json.Unmarshal(body, &telemostErr); err != nil {
+		return fmt.Errorf("failed to end meeting, status: %d, body: %s", statusCode, string(body))
+	}
+	return &TelemostAPIError{StatusCode: statusCode, Code: telemostErr.Error, Message: telemostErr.Message}
+}
+
+// CheckToken validates the OAuth token without side effects by reading a conference that doesn't
+// exist: the API answers 404 for a valid token and 401 or 403 otherwise
+func (tc *TelemostClient) CheckToken() error {
+	statusCode, body, err := tc.do(http.MethodGet, "/conferences/"+tokenCheckConferenceID, nil)
+	if err != nil {
+		return err
+	}
+
+	switch statusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("token is invalid or expired")
+	case http.StatusForbidden:
+		return fmt.Errorf("token lacks the Telemost permissions")
+	}
+
+	var telemostErr TelemostError
+	if err := json.Unmarshal(body, &telemostErr); err != nil || telemostErr.Error == "" {
+		return fmt.Errorf("unexpected status: %d", statusCode)
+	}
+	return &TelemostAPIError{StatusCode: statusCode, Code: telemostErr.Error, Message: telemostErr.Message}
+}
+
+// do sends a request to the Telemost API and returns the status code and body. Rate limited
+// requests (429) are retried with a short backoff, as are 503 responses to idempotent requests.
+// A POST may have created a conference before failing with 503, so it isn't repeated.
+func (tc *TelemostClient) do(method, endpoint string, payload []byte) (int, []byte, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequest(method, telemostAPIBaseURL+endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		httpReq.Header.Set("Authorization", "OAuth "+tc.oauthToken)
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		started := time.Now()
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			tc.metrics.ObserveTelemostRequest(method, endpointLabel(endpoint), "error", time.Since(started))
+			return 0, nil, fmt.Errorf("failed to make request: %w", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		tc.metrics.ObserveTelemostRequest(method, endpointLabel(endpoint), strconv.Itoa(resp.StatusCode), time.Since(started))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests ||
+			(resp.StatusCode == http.StatusServiceUnavailable && method != http.MethodPost)
+		if !retryable || attempt >= telemostMaxRetries {
+			return resp.StatusCode, body, nil
+		}
+
+		tc.metrics.IncTelemostRetry(method, endpointLabel(endpoint))
+		time.Sleep(time.Duration(attempt+1) * telemostRetryBackoff)
+	}
+}
+
+// endpointLabel replaces the conference ID in an endpoint so metrics don't get a series per meeting
+func endpointLabel(endpoint string) string {
+	parts := strings.Split(endpoint, "/")
+	if len(parts) > 2 && parts[1] == "conferences" {
+		parts[2] = "{id}"
+	}
+	return strings.Join(parts, "/")
+}
+
+// meetingDefaults provides the default settings applied to new meetings
+type meetingDefaults interface {
 	GetDefaultWaitingRoomLevel() string
 	IsLiveStreamEnabled() bool
 	GetDefaultLiveStreamAccessLevel() string
-}, title string, description string, cohosts []string) (*TelemostMeeting, error) {
+}
+
+// withoutLiveStream overrides meeting defaults to never start a live stream
+type withoutLiveStream struct {
+	meetingDefaults
+}
+
+// IsLiveStreamEnabled always returns false
+func (withoutLiveStream) IsLiveStreamEnabled() bool {
+	return false
+}
+
+// withWaitingRoom overrides meeting defaults with the given waiting room level
+type withWaitingRoom struct {
+	meetingDefaults
+	level string
+}
+
+// GetDefaultWaitingRoomLevel returns the requested waiting room level
+func (w withWaitingRoom) GetDefaultWaitingRoomLevel() string {
+	return w.level
+}
+
+// withLiveStream overrides meeting defaults to start a live stream with the given access level
+type withLiveStream struct {
+	meetingDefaults
+	accessLevel string
+}
+
+// IsLiveStreamEnabled always returns true
+func (withLiveStream) IsLiveStreamEnabled() bool {
+	return true
+}
+
+// GetDefaultLiveStreamAccessLevel returns the requested access level
+func (w withLiveStream) GetDefaultLiveStreamAccessLevel() string {
+	return w.accessLevel
+}
+
+// CreateMeetingWithDefaults creates a meeting with default settings from configuration
+func (tc *TelemostClient) CreateMeetingWithDefaults(config meetingDefaults, title string, description string, cohosts []string) (*TelemostMeeting, error) {
 	req := &TelemostCreateRequest{
 		WaitingRoomLevel: config.GetDefaultWaitingRoomLevel(),
 	}
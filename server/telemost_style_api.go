@@ -2,10 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 )
 
@@ -23,6 +28,26 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 	switch {
 	case path == "/api/v1/meetings":
 		p.handleCreateMeeting(w, r)
+	case path == "/api/v1/meetings/thread":
+		p.handleThreadMeeting(w, r)
+	case path == "/api/v1/metrics":
+		p.handleMetrics(w, r)
+	case path == "/api/v1/stats":
+		p.handleStats(w, r)
+	case path == "/api/v1/audit/export":
+		p.handleAuditExport(w, r)
+	case path == "/api/v1/users/export":
+		p.handleUserExport(w, r)
+	case path == command.MeetingListActionPath:
+		p.handleMeetingListAction(w, r)
+	case path == "/api/v1/calls/respond":
+		p.handleCallResponse(w, r)
+	case path == command.AutocompleteMeetingsPath:
+		p.handleAutocompleteMeetings(w, r)
+	case path == command.AutocompleteTemplatesPath:
+		p.handleAutocompleteTemplates(w, r)
+	case strings.HasPrefix(path, incomingWebhookPathPrefix):
+		p.handleIncomingWebhook(w, r)
 	case path == "/oauth/start":
 		p.handleOAuthStart(w, r)
 	case path == "/oauth/callback":
@@ -115,6 +140,7 @@ func (p *Plugin) handleCreateMeeting(w http.ResponseWriter, r *http.Request) {
 		Title       string   `json:"title"`
 		Description string   `json:"description"`
 		Cohosts     []string `json:"cohosts"`
+		ChannelID   string   `json:"channel_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -122,19 +148,36 @@ func (p *Plugin) handleCreateMeeting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user token from request context or headers
-	// This is a simplified implementation - you may need to adjust based on your auth flow
-	userID := r.Header.Get("X-User-ID")
+	// The user is authenticated by the Mattermost server, never by the request itself
+	userID := r.Header.Get("Mattermost-User-Id")
 	if userID == "" {
 		http.Error(w, "User not authenticated", http.StatusUnauthorized)
 		return
 	}
 
-	// Create meeting using Telemost client
-	meeting, err := p.telemostClient.CreateMeetingWithDefaults(p.getConfiguration(), req.Title, req.Description, req.Cohosts)
+	// Channel restrictions are checked against the channel, so it can't be left out, and the
+	// meeting is created on behalf of the user, who must be able to post there
+	if req.ChannelID == "" {
+		http.Error(w, "channel_id is required", http.StatusBadRequest)
+		return
+	}
+	if !p.API.HasPermissionToChannel(userID, req.ChannelID, model.PermissionCreatePost) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// The meeting service creates the meeting with the user's own token, validated the same way
+	// as for the slash command, or on the channel's Jitsi server
+	meeting, err := p.meetings.CreateMeeting(&command.MeetingRequest{
+		UserID:      userID,
+		ChannelID:   req.ChannelID,
+		Title:       req.Title,
+		Description: req.Description,
+		Cohosts:     req.Cohosts,
+		Source:      "api",
+	})
 	if err != nil {
-		p.API.LogError("Failed to create meeting", "error", err.Error())
-		http.Error(w, "Failed to create meeting", http.StatusInternalServerError)
+		writeMeetingError(w, err)
 		return
 	}
 
@@ -142,3 +185,24 @@ func (p *Plugin) handleCreateMeeting(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(meeting)
 }
+
+// writeMeetingError maps a meeting creation error to an HTTP response
+func writeMeetingError(w http.ResponseWriter, err error) {
+	var rateErr *command.RateLimitError
+	var permissionErr *command.PermissionError
+	var unsupportedErr *command.UnsupportedError
+	var notConnectedErr *command.NotConnectedError
+	switch {
+	case errors.As(err, &notConnectedErr):
+		http.Error(w, "User not authenticated with Telemost", http.StatusUnauthorized)
+	case errors.As(err, &rateErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+		http.Error(w, rateErr.Error(), http.StatusTooManyRequests)
+	case errors.As(err, &permissionErr):
+		http.Error(w, permissionErr.Error(), http.StatusForbidden)
+	case errors.As(err, &unsupportedErr):
+		http.Error(w, unsupportedErr.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to create meeting", http.StatusInternalServerError)
+	}
+}
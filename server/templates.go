@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/pkg/errors"
+)
+
+// templateVariables are the variables templates can use
+var templateVariables = map[string]bool{
+	"date":           true,
+	"time":           true,
+	"weekday":        true,
+	"channel":        true,
+	"team":           true,
+	"user":           true,
+	"user_full_name": true,
+}
+
+// meetingTemplate is a named set of meeting settings defined by admins
+type meetingTemplate struct {
+	Name             string   `json:"name"`
+	Title            string   `json:"title,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	WaitingRoomLevel string   `json:"waiting_room_level,omitempty"`
+	LiveStream       string   `json:"live_stream,omitempty"`
+	Cohosts          []string `json:"cohosts,omitempty"`
+	CohostGroups     []string `json:"cohost_groups,omitempty"`
+	Persistent       bool     `json:"persistent,omitempty"`
+}
+
+// validate checks the template's settings and the variables its title and description use
+func (t *meetingTemplate) validate() []string {
+	problems := []string{}
+	if t.WaitingRoomLevel != "" && !containsString(waitingRoomLevels, t.WaitingRoomLevel) {
+		problems = append(problems, fmt.Sprintf("meeting template %q: waiting_room_level must be one of %s, got %q", t.Name, strings.Join(waitingRoomLevels, ", "), t.WaitingRoomLevel))
+	}
+	if t.LiveStream != "" && t.LiveStream != command.LiveStreamOff && !containsString(liveStreamAccessLevels, t.LiveStream) {
+		problems = append(problems, fmt.Sprintf("meeting template %q: live_stream must be one of %s or %s, got %q", t.Name, strings.Join(liveStreamAccessLevels, ", "), command.LiveStreamOff, t.LiveStream))
+	}
+	for _, text := range []string{t.Title, t.Description} {
+		for _, match := range command.PlaceholderPattern.FindAllStringSubmatch(text, -1) {
+			if !templateVariables[match[1]] {
+				problems = append(problems, fmt.Sprintf("meeting template %q uses unknown variable {{%s}}", t.Name, match[1]))
+			}
+		}
+	}
+	return problems
+}
+
+// parseMeetingTemplates parses the configured meeting templates
+func (c *configuration) parseMeetingTemplates() ([]meetingTemplate, error) {
+	if strings.TrimSpace(c.MeetingTemplates) == "" {
+		return nil, nil
+	}
+
+	var templates []meetingTemplate
+	if err := json.Unmarshal([]byte(c.MeetingTemplates), &templates); err != nil {
+		return nil, errors.Wrap(err, "failed to parse meeting templates")
+	}
+	return templates, nil
+}
+
+// validateMeetingTemplates checks that every template is parseable, named uniquely and valid
+func (c *configuration) validateMeetingTemplates() []string {
+	templates, err := c.parseMeetingTemplates()
+	if err != nil {
+		return []string{fmt.Sprintf("Meeting Templates must be a JSON array: %s", errors.Cause(err).Error())}
+	}
+
+	problems := []string{}
+	seen := map[string]bool{}
+	for i := range templates {
+		template := &templates[i]
+		switch {
+		case template.Name == "":
+			problems = append(problems, fmt.Sprintf("meeting template #%d has no name", i+1))
+		case strings.ContainsAny(template.Name, " \t"):
+			problems = append(problems, fmt.Sprintf("meeting template %q must not contain spaces", template.Name))
+		case seen[strings.ToLower(template.Name)]:
+			problems = append(problems, fmt.Sprintf("meeting template %q is defined more than once", template.Name))
+		default:
+			problems = append(problems, template.validate()...)
+		}
+		seen[strings.ToLower(template.Name)] = true
+	}
+	return problems
+}
+
+// getMeetingTemplate returns the meeting template with the given name, ignoring case, or nil
+func (c *configuration) getMeetingTemplate(name string) (*meetingTemplate, error) {
+	templates, err := c.parseMeetingTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range templates {
+		if strings.EqualFold(templates[i].Name, name) {
+			return &templates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// GetMeetingTemplates describes the configured meeting templates
+func (s *meetingService) GetMeetingTemplates() ([]command.MeetingTemplateInfo, error) {
+	templates, err := s.p.getConfiguration().parseMeetingTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]command.MeetingTemplateInfo, 0, len(templates))
+	for _, template := range templates {
+		infos = append(infos, command.MeetingTemplateInfo{
+			Name:  template.Name,
+			Title: template.Title,
+		})
+	}
+	return infos, nil
+}
+
+// ApplyMeetingTemplate merges the named template into a meeting request: the rendered title and
+// description replace the request's, cohosts are added, the waiting room and live stream
+// settings override the configured defaults and persistent templates exempt the meeting from the
+// auto-end policy
+func (s *meetingService) ApplyMeetingTemplate(name string, req *command.MeetingRequest) error {
+	template, err := s.p.getConfiguration().getMeetingTemplate(name)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return &command.TemplateNotFoundError{Name: name}
+	}
+
+	variables := s.p.templateVariableValues(req.UserID, req.ChannelID, time.Now())
+	if template.Title != "" {
+		req.Title = command.RenderPlaceholders(template.Title, variables)
+	}
+	if template.Description != "" {
+		req.Description = command.RenderPlaceholders(template.Description, variables)
+	}
+	if template.WaitingRoomLevel != "" {
+		req.WaitingRoomLevel = template.WaitingRoomLevel
+	}
+	if template.LiveStream != "" {
+		req.LiveStream = template.LiveStream
+	}
+	if template.Persistent {
+		req.Persistent = true
+	}
+
+	cohosts := append([]string{}, template.Cohosts...)
+	for _, groupName := range template.CohostGroups {
+		emails, err := s.p.groupMemberEmails(groupName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load members of group %q", groupName)
+		}
+		cohosts = append(cohosts, emails...)
+	}
+	req.Cohosts = mergeEmails(req.Cohosts, cohosts)
+
+	return nil
+}
+
+// templateVariableValues looks up the values of the template variables for a user and channel.
+// Dates and times are in the user's timezone.
+func (p *Plugin) templateVariableValues(userID, channelID string, now time.Time) map[string]string {
+	user, appErr := p.API.GetUser(userID)
+	if appErr == nil {
+		now = command.UserTime(user, now)
+	}
+	values := command.TimePlaceholders(now)
+
+	if appErr == nil {
+		values["user"] = user.Username
+		values["user_full_name"] = user.GetFullName()
+		if values["user_full_name"] == "" {
+			values["user_full_name"] = user.Username
+		}
+	}
+
+	if channel, appErr := p.API.GetChannel(channelID); appErr == nil {
+		values["channel"] = channel.DisplayName
+		if channel.TeamId != "" {
+			if team, appErr := p.API.GetTeam(channel.TeamId); appErr == nil {
+				values["team"] = team.DisplayName
+			}
+		}
+	}
+	return values
+}
+
+// groupMemberEmails returns the emails of the active human members of a user group
+func (p *Plugin) groupMemberEmails(groupName string) ([]string, error) {
+	group, err := p.client.Group.GetByName(strings.TrimPrefix(groupName, "@"))
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := command.GroupMembers(p.client, group.Id)
+	if err != nil {
+		return nil, err
+	}
+	emails := []string{}
+	for _, member := range members {
+		if member.Email != "" {
+			emails = append(emails, member.Email)
+		}
+	}
+	return emails, nil
+}
+
+// mergeEmails appends the emails not in existing yet, ignoring case
+func mergeEmails(existing, emails []string) []string {
+	seen := map[string]bool{}
+	for _, email := range existing {
+		seen[strings.ToLower(email)] = true
+	}
+	for _, email := range emails {
+		if !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			existing = append(existing, email)
+		}
+	}
+	return existing
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	defaultMeetingTitle  = "Telemost Meeting"
+	maxMeetingTitleRunes = 100
+)
+
+// handleThreadMeeting creates a meeting about a post and posts the meeting card as a reply
+// in the post's thread. The post author and the thread participants become cohosts.
+func (p *Plugin) handleThreadMeeting(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		PostID string `json:"post_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PostID == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	post, appErr := p.API.GetPost(req.PostID)
+	if appErr != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// The meeting card is posted into the thread, so the user must be allowed to post there
+	if !p.API.HasPermissionToChannel(userID, post.ChannelId, model.PermissionCreatePost) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	rootID := post.RootId
+	if rootID == "" {
+		rootID = post.Id
+	}
+
+	cohosts, err := p.threadCohosts(rootID, userID)
+	if err != nil {
+		p.API.LogError("Failed to collect thread participants", "post_id", rootID, "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	title := meetingTitleFromMessage(post.Message)
+	meeting, err := p.meetings.CreateMeeting(&command.MeetingRequest{
+		UserID:    userID,
+		ChannelID: post.ChannelId,
+		Title:     title,
+		Cohosts:   cohosts,
+		Source:    "thread",
+	})
+	if err != nil {
+		message := command.MeetingErrorMessage(err)
+		if message == "" {
+			message = fmt.Sprintf("**❌ Failed to create meeting!**\n\nError: %s", err.Error())
+		}
+		p.sendEphemeral(userID, post.ChannelId, rootID, message)
+		writeMeetingError(w, err)
+		return
+	}
+
+	pretext := fmt.Sprintf("@%s has started a meeting about this thread", p.displayUsername(userID))
+	reply := command.NewMeetingPost(p.botUserID, userID, post.ChannelId, meeting, title, pretext)
+	reply.RootId = rootID
+	if _, appErr := p.API.CreatePost(reply); appErr != nil {
+		p.API.LogError("Failed to post meeting card", "error", appErr.Error())
+		http.Error(w, "Failed to post meeting", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meeting)
+}
+
+// threadCohosts returns the emails of the authors of all posts in a thread, except the
+// organizer and bots.
+func (p *Plugin) threadCohosts(rootID, organizerID string) ([]string, error) {
+	thread, appErr := p.API.GetPostThread(rootID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	seen := map[string]bool{organizerID: true}
+	cohosts := []string{}
+	for _, threadPost := range thread.Posts {
+		if seen[threadPost.UserId] {
+			continue
+		}
+		seen[threadPost.UserId] = true
+
+		user, appErr := p.API.GetUser(threadPost.UserId)
+		if appErr != nil {
+			p.API.LogWarn("Failed to get thread participant", "user_id", threadPost.UserId, "error", appErr.Error())
+			continue
+		}
+		if user.IsBot || user.Email == "" {
+			continue
+		}
+		cohosts = append(cohosts, user.Email)
+	}
+
+	return cohosts, nil
+}
+
+// meetingTitleFromMessage derives a meeting title from the first non-empty line of a message
+func meetingTitleFromMessage(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#>*_ "))
+		if line == "" {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) > maxMeetingTitleRunes {
+			return string(runes[:maxMeetingTitleRunes-1]) + "…"
+		}
+		return line
+	}
+	return defaultMeetingTitle
+}
+
+// displayUsername returns the username for mentions, falling back to the ID
+func (p *Plugin) displayUsername(userID string) string {
+	if user, appErr := p.API.GetUser(userID); appErr == nil {
+		return user.Username
+	}
+	return userID
+}
+
+// sendEphemeral shows a message from the bot only to the given user
+func (p *Plugin) sendEphemeral(userID, channelID, rootID, message string) {
+	p.API.SendEphemeralPost(userID, &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: channelID,
+		RootId:    rootID,
+		Message:   message,
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// tokenRefreshAhead is how long before expiry the background job refreshes tokens that have a
+// refresh token, independently of the reminder window
+const tokenRefreshAhead = 24 * time.Hour
+
+// refreshUserToken exchanges the refresh token for a new access token and stores it. Each
+// attempt is audited.
+func (p *Plugin) refreshUserToken(userToken *UserToken) (*UserToken, error) {
+	config := p.getConfiguration()
+	if userToken.RefreshToken == "" || config.YandexClientSecret == "" {
+		return nil, fmt.Errorf("token can't be refreshed")
+	}
+
+	refreshed, err := p.exchangeRefreshToken(config, userToken)
+	if err != nil {
+		p.recordAudit(audit.EventTokenRefresh, userToken.UserID, "", false, map[string]string{"error": err.Error()})
+		return nil, err
+	}
+	p.recordAudit(audit.EventTokenRefresh, userToken.UserID, "", true, map[string]string{"expires_at": refreshed.ExpiresAt.UTC().Format(time.RFC3339)})
+	return refreshed, nil
+}
+
+// exchangeRefreshToken gets a new access token from Yandex and stores it
+func (p *Plugin) exchangeRefreshToken(config *configuration, userToken *UserToken) (*UserToken, error) {
+	token, err := refreshOAuthToken(config.YandexClientID, config.YandexClientSecret, userToken.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := *userToken
+	refreshed.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	refreshed.ExpiresAt = token.expiresAt()
+	refreshed.ExpiryNotifiedAt = time.Time{}
+
+	if err := p.saveUserToken(&refreshed); err != nil {
+		return nil, err
+	}
+	return &refreshed, nil
+}
+
+// checkTokenExpiry refreshes tokens that expire soon and reminds users whose tokens can't be
+// refreshed, once per token, to reconnect
+func (p *Plugin) checkTokenExpiry() {
+	window := time.Duration(p.getConfiguration().TokenExpiryReminderHours) * time.Hour
+
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, userTokenKeyPrefix)
+	if err != nil {
+		p.API.LogError("Failed to list user tokens", "error", err.Error())
+		return
+	}
+
+	for _, key := range keys {
+		var userToken UserToken
+		if err := p.client.KV.Get(key, &userToken); err != nil || userToken.UserID == "" {
+			continue
+		}
+
+		remaining := time.Until(userToken.ExpiresAt)
+		if remaining > max(window, tokenRefreshAhead) {
+			continue
+		}
+
+		if userToken.RefreshToken != "" {
+			_, err := p.refreshUserToken(&userToken)
+			if err == nil {
+				continue
+			}
+			p.API.LogWarn("Failed to refresh user token", "user_id", userToken.UserID, "error", err.Error())
+		}
+
+		// Expired tokens are removed the next time they are used
+		if remaining <= 0 || remaining > window || !userToken.ExpiryNotifiedAt.IsZero() {
+			continue
+		}
+		p.sendExpiryReminder(&userToken)
+	}
+}
+
+// sendExpiryReminder sends the user a DM from the bot with a link to reconnect
+func (p *Plugin) sendExpiryReminder(userToken *UserToken) {
+	channel, err := p.getBotDirectChannel(userToken.UserID)
+	if err != nil {
+		p.API.LogError("Failed to get direct channel for expiry reminder", "user_id", userToken.UserID, "error", err.Error())
+		return
+	}
+
+	reconnectURL := p.connectURL(channel.Id)
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: channel.Id,
+		Message: fmt.Sprintf(
+			"⏳ **Your Telemost connection expires soon**\n\nYour Telemost authentication expires on %s UTC. [**Reconnect to Telemost**](%s) to keep creating meetings.",
+			userToken.ExpiresAt.UTC().Format("2006-01-02 15:04"),
+			reconnectURL,
+		),
+	}
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		p.API.LogError("Failed to send expiry reminder", "user_id", userToken.UserID, "error", appErr.Error())
+		return
+	}
+
+	userToken.ExpiryNotifiedAt = time.Now()
+	if err := p.saveUserToken(userToken); err != nil {
+		p.API.LogError("Failed to store expiry reminder", "user_id", userToken.UserID, "error", err.Error())
+	}
+}
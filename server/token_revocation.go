@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	tokenRevocationKeyPrefix   = "telemost_token_revocation_"
+	tokenRevocationMaxAttempts = 6
+)
+
+// pendingTokenRevocation is a removed token that Yandex couldn't be reached to revoke, queued in
+// KV until the revocation goes through
+type pendingTokenRevocation struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	AccessToken   string    `json:"access_token"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// removeUserToken deletes a user's token and revokes it with Yandex. The revocation is nil when
+// the user had no token.
+func (p *Plugin) removeUserToken(userID string) (*command.TokenRevocation, error) {
+	var userToken UserToken
+	if err := p.client.KV.Get(userTokenKeyPrefix+userID, &userToken); err != nil {
+		return nil, err
+	}
+	if userToken.UserID == "" {
+		return nil, nil
+	}
+
+	if err := p.client.KV.Delete(userTokenKeyPrefix + userID); err != nil {
+		return nil, err
+	}
+	return p.revokeUserToken(&userToken), nil
+}
+
+// revokeUserToken revokes a removed token with Yandex, so it can't be used outside the plugin
+// either. Network and server errors queue the revocation for the background job.
+func (p *Plugin) revokeUserToken(userToken *UserToken) *command.TokenRevocation {
+	revocation, err := p.tryTokenRevocation(userToken)
+	if err == nil {
+		return revocation
+	}
+
+	p.queueTokenRevocation(&pendingTokenRevocation{
+		ID:          model.NewId(),
+		UserID:      userToken.UserID,
+		AccessToken: userToken.AccessToken,
+	}, err)
+	return &command.TokenRevocation{Status: command.TokenRevocationQueued, Error: err.Error()}
+}
+
+// tryTokenRevocation revokes a removed token with Yandex once. Network and server errors, which
+// are worth retrying, are returned as errors; other outcomes are returned as the revocation.
+func (p *Plugin) tryTokenRevocation(userToken *UserToken) (*command.TokenRevocation, error) {
+	if userToken.RefreshToken == "" && time.Now().After(userToken.ExpiresAt) {
+		return &command.TokenRevocation{Status: command.TokenRevocationExpired}, nil
+	}
+
+	if p.getConfiguration().YandexClientSecret == "" {
+		return &command.TokenRevocation{Status: command.TokenRevocationFailed, Error: "revoking tokens requires the Yandex OAuth Client Secret"}, nil
+	}
+
+	err := p.sendTokenRevocation(userToken.AccessToken)
+	var rejected *oauthRevokeError
+	switch {
+	case err == nil:
+		return &command.TokenRevocation{Status: command.TokenRevoked}, nil
+	case errors.As(err, &rejected):
+		p.API.LogWarn("Yandex rejected token revocation", "user_id", userToken.UserID, "error", err.Error())
+		return &command.TokenRevocation{Status: command.TokenRevocationFailed, Error: err.Error()}, nil
+	}
+	return nil, err
+}
+
+// sendTokenRevocation revokes an access token with the configured OAuth application
+func (p *Plugin) sendTokenRevocation(accessToken string) error {
+	config := p.getConfiguration()
+	return revokeOAuthToken(config.YandexClientID, config.YandexClientSecret, accessToken)
+}
+
+// queueTokenRevocation records a failed revocation attempt and schedules the next one, giving up
+// after tokenRevocationMaxAttempts
+func (p *Plugin) queueTokenRevocation(revocation *pendingTokenRevocation, err error) {
+	revocation.Attempts++
+	revocation.LastError = err.Error()
+	if revocation.Attempts >= tokenRevocationMaxAttempts {
+		p.API.LogError("Giving up on token revocation", "user_id", revocation.UserID, "attempts", revocation.Attempts, "error", err.Error())
+		p.client.KV.Delete(tokenRevocationKeyPrefix + revocation.ID)
+		return
+	}
+
+	// Back off exponentially like webhook deliveries, bounded by how often the job runs
+	revocation.NextAttemptAt = time.Now().Add(time.Duration(1<<revocation.Attempts) * time.Minute)
+	if _, err := p.client.KV.Set(tokenRevocationKeyPrefix+revocation.ID, revocation); err != nil {
+		p.API.LogError("Failed to queue token revocation", "user_id", revocation.UserID, "error", err.Error())
+	}
+}
+
+// retryTokenRevocations retries queued token revocations that are due
+func (p *Plugin) retryTokenRevocations() {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, tokenRevocationKeyPrefix)
+	if err != nil {
+		p.API.LogError("Failed to list queued token revocations", "error", err.Error())
+		return
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		var revocation pendingTokenRevocation
+		if err := p.client.KV.Get(key, &revocation); err != nil || revocation.ID == "" {
+			continue
+		}
+		if revocation.NextAttemptAt.After(now) {
+			continue
+		}
+
+		err := p.sendTokenRevocation(revocation.AccessToken)
+		var rejected *oauthRevokeError
+		switch {
+		case err == nil:
+			p.API.LogInfo("Revoked queued token", "user_id", revocation.UserID, "attempts", revocation.Attempts+1)
+			p.client.KV.Delete(key)
+		case errors.As(err, &rejected):
+			p.API.LogWarn("Yandex rejected queued token revocation", "user_id", revocation.UserID, "error", err.Error())
+			p.client.KV.Delete(key)
+		default:
+			p.queueTokenRevocation(&revocation, err)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/pkg/errors"
+)
+
+const (
+	deactivationKeyPrefix   = "telemost_deactivation_"
+	deactivationMaxAttempts = 6
+)
+
+// userQueues are the user's entries in the KV queues, which aren't named after the user
+type userQueues struct {
+	oauthStateKeys      []string
+	webhookDeliveryKeys []string
+	tokenRevocationKeys []string
+	queuedAccessTokens  []string
+}
+
+// GetUserData gathers everything the plugin stores about a user. Tokens are left out.
+func (s *adminService) GetUserData(userID string) (*command.UserData, error) {
+	data, _, err := s.p.userData(userID)
+	return data, err
+}
+
+// PurgeUserData deletes everything the plugin stores about a user and returns what was deleted,
+// with the outcome of revoking the user's token if they were connected. Queued revocations of
+// the user's older tokens are attempted once more, so no token is left behind. The purge itself
+// is audited as an action of the admin.
+func (s *adminService) PurgeUserData(adminID, userID string) (*command.UserData, *command.TokenRevocation, error) {
+	data, queues, err := s.p.userData(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var userToken UserToken
+	if err := s.p.client.KV.Get(userTokenKeyPrefix+userID, &userToken); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load token")
+	}
+
+	keys := []string{
+		userTokenKeyPrefix + userID,
+		lastMeetingKeyPrefix + userID,
+		rateLimitKeyPrefix + "user_" + userID,
+		statsOrganizerPrefix + userID,
+		deactivationKeyPrefix + userID,
+	}
+	keys = append(keys, queues.oauthStateKeys...)
+	keys = append(keys, queues.webhookDeliveryKeys...)
+	keys = append(keys, queues.tokenRevocationKeys...)
+	for _, key := range keys {
+		if err := s.p.client.KV.Delete(key); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to delete %s", key)
+		}
+	}
+	for i := range data.Meetings {
+		if err := s.p.deleteMeetingRecord(&data.Meetings[i]); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to delete meeting %s", data.Meetings[i].ID)
+		}
+	}
+
+	if _, err := s.p.audit.DeleteUser(userID); err != nil {
+		return nil, nil, err
+	}
+
+	details := map[string]string{"target_user_id": userID}
+	var revocation *command.TokenRevocation
+	if userToken.UserID != "" {
+		revocation = s.p.purgeTokenRevocation(&userToken)
+		details["token_revocation"] = revocation.Status
+	}
+	unrevoked := 0
+	for _, accessToken := range queues.queuedAccessTokens {
+		if err := s.p.sendTokenRevocation(accessToken); err != nil {
+			s.p.API.LogWarn("Failed to revoke queued token of purged user", "user_id", userID, "error", err.Error())
+			unrevoked++
+		}
+	}
+	if len(queues.queuedAccessTokens) > 0 {
+		details["queued_token_revocations"] = strconv.Itoa(len(queues.queuedAccessTokens))
+		details["queued_token_revocations_failed"] = strconv.Itoa(unrevoked)
+	}
+
+	s.p.recordAudit(audit.EventUserPurge, adminID, "", true, details)
+	if data.Connection != nil {
+		s.p.emitEvent(eventUserDisconnected, map[string]interface{}{"user_id": userID})
+	}
+	return data, revocation, nil
+}
+
+// purgeTokenRevocation revokes a deleted token with Yandex once. Unlike revokeUserToken, failures
+// aren't queued, since the queue would keep the token the purge deleted.
+func (p *Plugin) purgeTokenRevocation(userToken *UserToken) *command.TokenRevocation {
+	revocation, err := p.tryTokenRevocation(userToken)
+	if err != nil {
+		p.API.LogWarn("Failed to revoke token of purged user", "user_id", userToken.UserID, "error", err.Error())
+		return &command.TokenRevocation{Status: command.TokenRevocationFailed, Error: err.Error()}
+	}
+	return revocation
+}
+
+// userData gathers everything the plugin stores about a user, together with the user's entries
+// in the KV queues
+func (p *Plugin) userData(userID string) (*command.UserData, *userQueues, error) {
+	data := &command.UserData{UserID: userID}
+	queues := &userQueues{}
+
+	var userToken UserToken
+	if err := p.client.KV.Get(userTokenKeyPrefix+userID, &userToken); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load token")
+	}
+	if userToken.UserID != "" {
+		data.Connection = &command.UserConnection{
+			ConnectedAt: userToken.ConnectedAt,
+			ExpiresAt:   userToken.ExpiresAt,
+			Refreshable: userToken.RefreshToken != "",
+		}
+	}
+
+	var err error
+	queues.oauthStateKeys, data.OAuthStates, err = p.userOAuthStates(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if data.LastMeeting, err = p.getLastMeeting(userID); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load last meeting")
+	}
+
+	data.Meetings, err = p.listIndexedMeetingRecords(meetingCreatorIndexKeyPrefix+userID, func(record *command.MeetingRecord) bool {
+		return record.CreatorID == userID
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list meeting records")
+	}
+
+	if err := p.client.KV.Get(statsOrganizerPrefix+userID, &data.MeetingsCreated); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load statistics")
+	}
+
+	if data.AuditEvents, err = p.audit.Query(audit.Filter{UserID: userID}); err != nil {
+		return nil, nil, err
+	}
+
+	if err := p.userTokenRevocations(userID, data, queues); err != nil {
+		return nil, nil, err
+	}
+	if err := p.userWebhookDeliveries(userID, data, queues); err != nil {
+		return nil, nil, err
+	}
+
+	return data, queues, nil
+}
+
+// userTokenRevocations adds the queued revocations of the user's removed tokens
+func (p *Plugin) userTokenRevocations(userID string, data *command.UserData, queues *userQueues) error {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, tokenRevocationKeyPrefix)
+	if err != nil {
+		return errors.Wrap(err, "failed to list queued token revocations")
+	}
+
+	data.TokenRevocations = []command.QueuedTokenRevocation{}
+	for _, key := range keys {
+		var revocation pendingTokenRevocation
+		if err := p.client.KV.Get(key, &revocation); err != nil || revocation.UserID != userID {
+			continue
+		}
+		queues.tokenRevocationKeys = append(queues.tokenRevocationKeys, key)
+		queues.queuedAccessTokens = append(queues.queuedAccessTokens, revocation.AccessToken)
+		data.TokenRevocations = append(data.TokenRevocations, command.QueuedTokenRevocation{
+			Attempts:      revocation.Attempts,
+			LastError:     revocation.LastError,
+			NextAttemptAt: revocation.NextAttemptAt,
+		})
+	}
+	return nil
+}
+
+// userWebhookDeliveries adds the queued deliveries of events about the user
+func (p *Plugin) userWebhookDeliveries(userID string, data *command.UserData, queues *userQueues) error {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, webhookDeliveryKeyPrefix)
+	if err != nil {
+		return errors.Wrap(err, "failed to list queued webhook deliveries")
+	}
+
+	data.WebhookDeliveries = []command.QueuedWebhookDelivery{}
+	for _, key := range keys {
+		var delivery webhookDelivery
+		if err := p.client.KV.Get(key, &delivery); err != nil || delivery.ID == "" {
+			continue
+		}
+		var event outgoingEvent
+		if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+			continue
+		}
+		if eventUserID, _ := event.Data["user_id"].(string); eventUserID != userID {
+			continue
+		}
+		queues.webhookDeliveryKeys = append(queues.webhookDeliveryKeys, key)
+		data.WebhookDeliveries = append(data.WebhookDeliveries, command.QueuedWebhookDelivery{
+			EventType:     event.Type,
+			URL:           delivery.URL,
+			Attempts:      delivery.Attempts,
+			NextAttemptAt: delivery.NextAttemptAt,
+		})
+	}
+	return nil
+}
+
+// userOAuthStates returns the keys and contents of the user's pending OAuth states
+func (p *Plugin) userOAuthStates(userID string) ([]string, []command.UserOAuthState, error) {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, oauthStateKeyPrefix)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list OAuth states")
+	}
+
+	userKeys := []string{}
+	states := []command.UserOAuthState{}
+	for _, key := range keys {
+		var state OAuthState
+		if err := p.client.KV.Get(key, &state); err != nil || state.UserID != userID {
+			continue
+		}
+		userKeys = append(userKeys, key)
+		states = append(states, command.UserOAuthState{
+			ChannelID: state.ChannelID,
+			ExpiresAt: state.ExpiresAt,
+		})
+	}
+	return userKeys, states, nil
+}
+
+// UserHasBeenDeactivated removes the Telemost connection and pending OAuth flows of deactivated
+// users and revokes their tokens, so they can't be used anymore. Failures are queued for the
+// background job to retry.
+func (p *Plugin) UserHasBeenDeactivated(_ *plugin.Context, user *model.User) {
+	if err := p.cleanupDeactivatedUser(user.Id); err != nil {
+		p.recordAudit(audit.EventDisconnect, user.Id, "", false, map[string]string{"reason": "deactivated", "error": err.Error()})
+		p.API.LogError("Failed to clean up deactivated user", "user_id", user.Id, "error", err.Error())
+		p.queueDeactivation(&pendingDeactivation{UserID: user.Id}, err)
+	}
+}
+
+// cleanupDeactivatedUser removes the pending OAuth flows and the token of a deactivated user. It
+// can be repeated until it succeeds.
+func (p *Plugin) cleanupDeactivatedUser(userID string) error {
+	keys, _, err := p.userOAuthStates(userID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := p.client.KV.Delete(key); err != nil {
+			return errors.Wrap(err, "failed to delete OAuth state")
+		}
+	}
+
+	revocation, err := p.removeUserToken(userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove token")
+	}
+	if revocation == nil {
+		return nil
+	}
+
+	details := map[string]string{"reason": "deactivated", "token_revocation": revocation.Status}
+	if revocation.Error != "" {
+		details["error"] = revocation.Error
+	}
+	p.recordAudit(audit.EventDisconnect, userID, "", true, details)
+	p.emitEvent(eventUserDisconnected, map[string]interface{}{"user_id": userID})
+	return nil
+}
+
+// pendingDeactivation is the cleanup of a deactivated user that failed, queued in KV until it
+// goes through
+type pendingDeactivation struct {
+	UserID        string    `json:"user_id"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// queueDeactivation records a failed cleanup attempt and schedules the next one, giving up after
+// deactivationMaxAttempts
+func (p *Plugin) queueDeactivation(deactivation *pendingDeactivation, err error) {
+	deactivation.Attempts++
+	deactivation.LastError = err.Error()
+	if deactivation.Attempts >= deactivationMaxAttempts {
+		p.API.LogError("Giving up on cleaning up deactivated user", "user_id", deactivation.UserID, "attempts", deactivation.Attempts, "error", err.Error())
+		p.client.KV.Delete(deactivationKeyPrefix + deactivation.UserID)
+		return
+	}
+
+	deactivation.NextAttemptAt = time.Now().Add(time.Duration(1<<deactivation.Attempts) * time.Minute)
+	if _, err := p.client.KV.Set(deactivationKeyPrefix+deactivation.UserID, deactivation); err != nil {
+		p.API.LogError("Failed to queue deactivated user cleanup", "user_id", deactivation.UserID, "error", err.Error())
+	}
+}
+
+// retryDeactivations retries the queued cleanups of deactivated users that are due. Users who
+// were reactivated in the meantime are left alone.
+func (p *Plugin) retryDeactivations() {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, deactivationKeyPrefix)
+	if err != nil {
+		p.API.LogError("Failed to list queued deactivated user cleanups", "error", err.Error())
+		return
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		var deactivation pendingDeactivation
+		if err := p.client.KV.Get(key, &deactivation); err != nil || deactivation.UserID == "" {
+			continue
+		}
+		if deactivation.NextAttemptAt.After(now) {
+			continue
+		}
+
+		if user, appErr := p.API.GetUser(deactivation.UserID); appErr == nil && user.DeleteAt == 0 {
+			p.client.KV.Delete(key)
+			continue
+		}
+
+		if err := p.cleanupDeactivatedUser(deactivation.UserID); err != nil {
+			p.API.LogWarn("Failed to clean up deactivated user", "user_id", deactivation.UserID, "error", err.Error())
+			p.queueDeactivation(&deactivation, err)
+			continue
+		}
+		p.API.LogInfo("Cleaned up deactivated user", "user_id", deactivation.UserID, "attempts", deactivation.Attempts+1)
+		p.client.KV.Delete(key)
+	}
+}
+
+// handleUserExport downloads everything the plugin stores about a user as JSON for system admins
+func (p *Plugin) handleUserExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	adminID := r.Header.Get("Mattermost-User-Id")
+	if adminID == "" || !p.API.HasPermissionTo(adminID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if !model.IsValidId(userID) {
+		http.Error(w, "Invalid user_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	data, _, err := p.userData(userID)
+	if err != nil {
+		p.API.LogError("Failed to export user data", "user_id", userID, "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	p.recordAudit(audit.EventUserExport, adminID, "", true, map[string]string{"target_user_id": userID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="telemost-user-`+userID+`.json"`)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		p.API.LogError("Failed to write user data export", "error", err.Error())
+	}
+}
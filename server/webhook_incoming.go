@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/audit"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/command"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	incomingWebhookPathPrefix = "/webhooks/incoming/"
+	webhookMeetingKeyPrefix   = "telemost_webhook_meeting_"
+	webhookSignatureHeader    = "X-Telemost-Signature"
+	webhookTimestampHeader    = "X-Telemost-Timestamp"
+	webhookMaxClockSkew       = 5 * time.Minute
+	webhookMaxBodyBytes       = 1 << 20
+)
+
+// incomingWebhook is an external system allowed to create meetings, as configured by admins
+type incomingWebhook struct {
+	Name     string   `json:"name"`
+	Secret   string   `json:"secret"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+// incomingWebhookRequest is the body external systems send to create a meeting
+type incomingWebhookRequest struct {
+	ExternalKey string   `json:"external_key"`
+	ChannelID   string   `json:"channel_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Message     string   `json:"message"`
+	Cohosts     []string `json:"cohosts"`
+}
+
+// incomingWebhookResponse is returned for both new and replayed requests
+type incomingWebhookResponse struct {
+	MeetingID string `json:"meeting_id"`
+	JoinURL   string `json:"join_url"`
+	ChannelID string `json:"channel_id"`
+	PostID    string `json:"post_id"`
+	Created   bool   `json:"created"`
+}
+
+// handleIncomingWebhook creates a meeting with the service account on behalf of an external
+// system and posts it to the target channel. Requests are signed with the integration's secret
+// as hex(HMAC-SHA256(secret, timestamp + "." + body)) and are idempotent on external_key.
+func (p *Plugin) handleIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, incomingWebhookPathPrefix)
+	integration, err := p.getConfiguration().getIncomingWebhook(name)
+	if err != nil {
+		p.API.LogError("Failed to load incoming webhooks", "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if integration == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, webhookMaxBodyBytes))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifyWebhookSignature(integration.Secret, r.Header.Get(webhookTimestampHeader), r.Header.Get(webhookSignatureHeader), body); err != nil {
+		p.recordAudit(audit.EventWebhook, "", "", false, map[string]string{"integration": name, "error": err.Error()})
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var req incomingWebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ExternalKey == "" || req.ChannelID == "" {
+		http.Error(w, "external_key and channel_id are required", http.StatusBadRequest)
+		return
+	}
+	if len(integration.Channels) > 0 && !containsString(integration.Channels, req.ChannelID) {
+		http.Error(w, "Channel not allowed for this integration", http.StatusForbidden)
+		return
+	}
+
+	provider, err := p.serviceMeetingProvider(req.ChannelID)
+	if err != nil {
+		http.Error(w, "Telemost OAuth token is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Serialize requests for the same external key across the cluster so retries can't create
+	// a second meeting while the first one is still being created
+	key := webhookMeetingKey(name, req.ExternalKey)
+	mutex, err := cluster.NewMutex(p.API, key)
+	if err != nil {
+		p.API.LogError("Failed to create webhook mutex", "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var existing incomingWebhookResponse
+	if err := p.client.KV.Get(key, &existing); err != nil {
+		p.API.LogError("Failed to load webhook meeting", "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := &existing
+	if existing.MeetingID == "" {
+		response, err = p.createWebhookMeeting(provider, &req)
+		if err != nil {
+			p.recordAudit(audit.EventWebhook, "", req.ChannelID, false, map[string]string{"integration": name, "external_key": req.ExternalKey, "error": err.Error()})
+			writeMeetingError(w, err)
+			return
+		}
+
+		// Remember the meeting before posting it, so a retry after a failed post posts this
+		// meeting instead of creating another one
+		if _, err := p.client.KV.Set(key, response); err != nil {
+			p.API.LogError("Failed to store webhook meeting", "error", err.Error())
+		}
+		p.recordAudit(audit.EventWebhook, "", req.ChannelID, true, map[string]string{"integration": name, "external_key": req.ExternalKey, "meeting_id": response.MeetingID})
+	} else {
+		existing.Created = false
+	}
+
+	if response.PostID == "" {
+		postID, err := p.postWebhookMeeting(name, &req, response)
+		if err != nil {
+			p.API.LogError("Failed to post webhook meeting", "meeting_id", response.MeetingID, "error", err.Error())
+			http.Error(w, "Failed to post meeting", http.StatusInternalServerError)
+			return
+		}
+
+		response.PostID = postID
+		stored := *response
+		stored.Created = false
+		if _, err := p.client.KV.Set(key, stored); err != nil {
+			p.API.LogError("Failed to store webhook meeting", "error", err.Error())
+		}
+	}
+
+	status := http.StatusOK
+	if response.Created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, response)
+}
+
+// createWebhookMeeting creates the meeting with the service account. It goes through the same
+// restrictions, rate limits and records as meetings created by users.
+func (p *Plugin) createWebhookMeeting(provider MeetingProvider, req *incomingWebhookRequest) (*incomingWebhookResponse, error) {
+	meeting, err := p.createMeeting(provider, &command.MeetingRequest{
+		ChannelID:   req.ChannelID,
+		Title:       webhookMeetingTitle(req),
+		Description: req.Description,
+		Cohosts:     req.Cohosts,
+		Source:      "webhook",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &incomingWebhookResponse{
+		MeetingID: meeting.ID,
+		JoinURL:   meeting.JoinURL,
+		ChannelID: req.ChannelID,
+		Created:   true,
+	}, nil
+}
+
+// postWebhookMeeting posts a webhook meeting to its channel as the bot and returns the post ID
+func (p *Plugin) postWebhookMeeting(name string, req *incomingWebhookRequest, response *incomingWebhookResponse) (string, error) {
+	meeting := &TelemostMeeting{ID: response.MeetingID, JoinURL: response.JoinURL}
+	post := command.NewMeetingPost(p.botUserID, "", response.ChannelID, meeting, webhookMeetingTitle(req), fmt.Sprintf("%s has started a meeting", name))
+	post.Message = req.Message
+	post.AddProp(model.PostPropsFromWebhook, "true")
+	created, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		return "", appErr
+	}
+	return created.Id, nil
+}
+
+func webhookMeetingTitle(req *incomingWebhookRequest) string {
+	if req.Title == "" {
+		return defaultMeetingTitle
+	}
+	return req.Title
+}
+
+// verifyWebhookSignature checks the HMAC signature and rejects stale timestamps to prevent replays
+func verifyWebhookSignature(secret, timestamp, signature string, body []byte) error {
+	if secret == "" {
+		return fmt.Errorf("integration has no secret")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s header", webhookTimestampHeader)
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > webhookMaxClockSkew || skew < -webhookMaxClockSkew {
+		return fmt.Errorf("timestamp outside the allowed window")
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return fmt.Errorf("invalid %s header", webhookSignatureHeader)
+	}
+	if !hmac.Equal(expected, signPayload(secret, timestamp, body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// signPayload computes HMAC-SHA256(secret, timestamp + "." + body)
+func signPayload(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// webhookMeetingKey hashes the external key so arbitrary values fit in a KV key
+func webhookMeetingKey(integration, externalKey string) string {
+	sum := sha256.Sum256([]byte(integration + "\x00" + externalKey))
+	return webhookMeetingKeyPrefix + hex.EncodeToString(sum[:16])
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
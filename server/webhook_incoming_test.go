@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"external_key":"INC-1","channel_id":"channelid"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-webhookMaxClockSkew-time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(webhookMaxClockSkew+time.Minute).Unix(), 10)
+	sign := func(timestamp string, body []byte) string {
+		return "sha256=" + hex.EncodeToString(signPayload(secret, timestamp, body))
+	}
+
+	for name, tc := range map[string]struct {
+		noSecret    bool
+		timestamp   string
+		signature   string
+		body        []byte
+		expectedErr string
+	}{
+		"valid signature": {
+			timestamp: now,
+			signature: sign(now, body),
+		},
+		"valid signature without prefix": {
+			timestamp: now,
+			signature: hex.EncodeToString(signPayload(secret, now, body)),
+		},
+		"tampered body": {
+			timestamp:   now,
+			signature:   sign(now, body),
+			body:        []byte(`{"external_key":"INC-1","channel_id":"otherchannel"}`),
+			expectedErr: "signature mismatch",
+		},
+		"signature of another timestamp": {
+			timestamp:   now,
+			signature:   sign(strconv.FormatInt(time.Now().Unix()-1, 10), body),
+			expectedErr: "signature mismatch",
+		},
+		"wrong secret": {
+			timestamp:   now,
+			signature:   "sha256=" + hex.EncodeToString(signPayload("other", now, body)),
+			expectedErr: "signature mismatch",
+		},
+		"stale timestamp": {
+			timestamp:   stale,
+			signature:   sign(stale, body),
+			expectedErr: "timestamp outside the allowed window",
+		},
+		"timestamp in the future": {
+			timestamp:   future,
+			signature:   sign(future, body),
+			expectedErr: "timestamp outside the allowed window",
+		},
+		"missing timestamp header": {
+			signature:   sign(now, body),
+			expectedErr: "missing or invalid X-Telemost-Timestamp header",
+		},
+		"missing signature header": {
+			timestamp:   now,
+			expectedErr: "signature mismatch",
+		},
+		"malformed signature header": {
+			timestamp:   now,
+			signature:   "sha256=not-hex",
+			expectedErr: "invalid X-Telemost-Signature header",
+		},
+		"integration without secret": {
+			noSecret:    true,
+			timestamp:   now,
+			signature:   sign(now, body),
+			expectedErr: "integration has no secret",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			integrationSecret := secret
+			if tc.noSecret {
+				integrationSecret = ""
+			}
+			requestBody := body
+			if tc.body != nil {
+				requestBody = tc.body
+			}
+
+			err := verifyWebhookSignature(integrationSecret, tc.timestamp, tc.signature, requestBody)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	eventMeetingCreated   = "meeting.created"
+	eventMeetingEnded     = "meeting.ended"
+	eventUserConnected    = "user.connected"
+	eventUserDisconnected = "user.disconnected"
+
+	webhookDeliveryKeyPrefix = "telemost_webhook_delivery_"
+	webhookMaxAttempts       = 6
+	webhookDeliveryTimeout   = 10 * time.Second
+)
+
+// outgoingEvent is the JSON body posted to the configured webhook URLs
+type outgoingEvent struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// webhookDelivery is a pending delivery of an event to one URL, queued in KV until it succeeds
+type webhookDelivery struct {
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+}
+
+// emitEvent sends an event to every configured webhook URL. Deliveries are attempted right away
+// in the background; failures are queued and retried by the background job.
+func (p *Plugin) emitEvent(eventType string, data map[string]interface{}) {
+	urls := parseWebhookURLs(p.getConfiguration().OutgoingWebhookURLs)
+	if len(urls) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(outgoingEvent{
+		ID:        model.NewId(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		p.API.LogError("Failed to marshal webhook event", "type", eventType, "error", err.Error())
+		return
+	}
+
+	for _, url := range urls {
+		delivery := &webhookDelivery{
+			ID:      model.NewId(),
+			URL:     url,
+			Payload: payload,
+		}
+		p.webhookDeliveries.Add(1)
+		go func() {
+			defer p.webhookDeliveries.Done()
+			p.attemptWebhookDelivery(delivery)
+		}()
+	}
+}
+
+// attemptWebhookDelivery sends a delivery once and queues it for a retry when it fails
+func (p *Plugin) attemptWebhookDelivery(delivery *webhookDelivery) {
+	err := p.sendWebhook(delivery)
+	if err == nil {
+		if delivery.Attempts > 0 {
+			p.client.KV.Delete(webhookDeliveryKeyPrefix + delivery.ID)
+		}
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= webhookMaxAttempts {
+		p.API.LogError("Giving up on webhook delivery", "url", delivery.URL, "attempts", delivery.Attempts, "error", err.Error())
+		p.client.KV.Delete(webhookDeliveryKeyPrefix + delivery.ID)
+		return
+	}
+
+	// Back off exponentially: 2, 4, 8, ... minutes, bounded by how often the job runs
+	delivery.NextAttemptAt = time.Now().Add(time.Duration(1<<delivery.Attempts) * time.Minute)
+	if _, err := p.client.KV.Set(webhookDeliveryKeyPrefix+delivery.ID, delivery); err != nil {
+		p.API.LogError("Failed to queue webhook delivery", "url", delivery.URL, "error", err.Error())
+	}
+}
+
+// retryWebhookDeliveries retries queued deliveries that are due
+func (p *Plugin) retryWebhookDeliveries() {
+	keys, err := kvstore.ListKeysWithPrefix(&p.client.KV, webhookDeliveryKeyPrefix)
+	if err != nil {
+		p.API.LogError("Failed to list queued webhook deliveries", "error", err.Error())
+		return
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		var delivery webhookDelivery
+		if err := p.client.KV.Get(key, &delivery); err != nil || delivery.ID == "" {
+			continue
+		}
+		if delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		p.attemptWebhookDelivery(&delivery)
+	}
+}
+
+// sendWebhook posts the payload, signed like incoming webhooks when a secret is configured
+func (p *Plugin) sendWebhook(delivery *webhookDelivery) error {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret := p.getConfiguration().OutgoingWebhookSecret; secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(signPayload(secret, timestamp, delivery.Payload)))
+	}
+
+	client := &http.Client{
+		Timeout: webhookDeliveryTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// parseWebhookURLs splits the setting on commas and whitespace
+func parseWebhookURLs(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	yandexUserInfoURL = "https://login.yandex.ru/info?format=json"
+)
+
+// YandexUserInfo is the Yandex account an OAuth token belongs to
+type YandexUserInfo struct {
+	ID           string `json:"id"`
+	Login        string `json:"login"`
+	DisplayName  string `json:"display_name"`
+	DefaultEmail string `json:"default_email"`
+}
+
+// YandexClient reads Yandex account information with a user's OAuth token
+type YandexClient struct {
+	oauthToken string
+}
+
+// NewYandexClient creates a new Yandex user-info client
+func NewYandexClient(oauthToken string) *YandexClient {
+	return &YandexClient{
+		oauthToken: oauthToken,
+	}
+}
+
+// GetUserInfo returns the account the token belongs to. The email is only present when the
+// application has the login:email scope.
+func (yc *YandexClient) GetUserInfo() (*YandexUserInfo, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, yandexUserInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "OAuth "+yc.oauthToken)
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get user info, status: %d", resp.StatusCode)
+	}
+
+	var info YandexUserInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// yandexToken is the response of the Yandex OAuth token endpoint
+type yandexToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// expiresAt converts expires_in to a time, assuming 24 hours when missing
+func (t *yandexToken) expiresAt() time.Time {
+	if t.ExpiresIn <= 0 {
+		return time.Now().Add(24 * time.Hour)
+	}
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// exchangeOAuthCode exchanges an authorization code for an access and refresh token
+func exchangeOAuthCode(clientID, clientSecret, code string) (*yandexToken, error) {
+	return requestOAuthToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+	})
+}
+
+// refreshOAuthToken gets a new access token with a refresh token
+func refreshOAuthToken(clientID, clientSecret, refreshToken string) (*yandexToken, error) {
+	return requestOAuthToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+	})
+}
+
+// requestOAuthToken posts a grant to the Yandex OAuth token endpoint
+func requestOAuthToken(form url.Values) (*yandexToken, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.PostForm(yandexOAuthURL+"/token", form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr OAuthError
+		if err := json.Unmarshal(body, &oauthErr); err != nil || oauthErr.Error == "" {
+			return nil, fmt.Errorf("failed to get token, status: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to get token: %s - %s", oauthErr.Error, oauthErr.Description)
+	}
+
+	var token yandexToken
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access token")
+	}
+
+	return &token, nil
+}
+
+// oauthRevokeError is a token revocation rejected by Yandex. Unlike network and server errors,
+// retrying it won't help.
+type oauthRevokeError struct {
+	Code        string
+	Description string
+}
+
+func (e *oauthRevokeError) Error() string {
+	if e.Code == "unsupported_token_type" {
+		return "Yandex only revokes tokens issued for a device, this token stays valid until it expires"
+	}
+	return fmt.Sprintf("failed to revoke token: %s - %s", e.Code, e.Description)
+}
+
+// revokeOAuthToken invalidates an access token with the Yandex OAuth revocation endpoint
+func revokeOAuthToken(clientID, clientSecret, accessToken string) error {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.PostForm(yandexOAuthURL+"/revoke_token", url.Values{
+		"access_token":  {accessToken},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("failed to revoke token, status: %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr OAuthError
+		if err := json.Unmarshal(body, &oauthErr); err != nil || oauthErr.Error == "" {
+			return &oauthRevokeError{Code: strconv.Itoa(resp.StatusCode), Description: http.StatusText(resp.StatusCode)}
+		}
+		return &oauthRevokeError{Code: oauthErr.Error, Description: oauthErr.Description}
+	}
+
+	return nil
+}
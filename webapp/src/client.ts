@@ -0,0 +1,23 @@
+// Copyright (c) 2015-present Mattermost, Inc.
+// All Rights Reserved. See LICENSE.txt for license information.
+
+import {Client4} from 'mattermost-redux/client';
+
+import manifest from '@/manifest';
+
+export const pluginURL = (path: string) => `/plugins/${manifest.id}${path}`;
+
+// doPost sends an authenticated JSON request to a plugin route
+export const doPost = async (path: string, body: unknown) => {
+    const response = await fetch(pluginURL(path), Client4.getOptions({
+        method: 'post',
+        body: JSON.stringify(body),
+    }));
+
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+        throw new Error(data.message || response.statusText);
+    }
+
+    return data;
+};
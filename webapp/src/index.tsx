@@ -2,16 +2,73 @@
 // All Rights Reserved. See LICENSE.txt for license information.
 
 import React from 'react';
+import {useSelector} from 'react-redux';
 
+import {getCurrentUserId} from 'mattermost-redux/selectors/entities/common';
+
+import {doPost} from '@/client';
 import manifest from '@/manifest';
 import type {PluginRegistry} from '@/types/mattermost-webapp';
 
+// Accept/Decline buttons and responses shown on ad-hoc calls started in DMs and GMs
+const CallResponse: React.FC<{post: any}> = ({post}) => {
+    const currentUserId = useSelector(getCurrentUserId);
+    const participants: string[] = post.props?.participants || [];
+    const accepted: string[] = post.props?.accepted || [];
+    const declined: string[] = post.props?.declined || [];
+
+    const respond = (action: 'accept' | 'decline') => {
+        doPost('/api/v1/calls/respond', {post_id: post.id, context: {action}}).then(() => {
+            if (action === 'accept') {
+                window.open(post.props.joinURL, '_blank', 'noopener');
+            }
+        }).catch((err) => {
+            console.error('Telemost: failed to respond to call', err);
+        });
+    };
+
+    return (
+        <div style={{marginTop: '8px'}}>
+            {participants.includes(currentUserId) && (
+                <div>
+                    <button
+                        className="btn btn-primary btn-sm"
+                        style={{marginRight: '8px'}}
+                        onClick={() => respond('accept')}
+                    >
+                        {'Accept'}
+                    </button>
+                    <button
+                        className="btn btn-tertiary btn-sm"
+                        onClick={() => respond('decline')}
+                    >
+                        {'Decline'}
+                    </button>
+                </div>
+            )}
+            {accepted.length > 0 && (
+                <div style={{fontSize: '12px', marginTop: '4px'}}>
+                    {'Accepted: '}{accepted.map((username) => `@${username}`).join(', ')}
+                </div>
+            )}
+            {declined.length > 0 && (
+                <div style={{fontSize: '12px', marginTop: '4px'}}>
+                    {'Declined: '}{declined.map((username) => `@${username}`).join(', ')}
+                </div>
+            )}
+        </div>
+    );
+};
+
 const TelemostPost: React.FC<{post: any}> = ({post}) => {
     const joinURL = post.props?.joinURL;
     const meetingID = post.props?.meetingID;
     const title = post.props?.title || 'Telemost Meeting';
     const pretext = post.props?.pretext || 'I have started a meeting';
 
+    // Set by the plugin once the meeting has ended, e.g. by the auto-end policy
+    const expired = Boolean(post.props?.expired);
+
     // Auto-open functionality removed to prevent unwanted redirects
 
     if (!joinURL) {
@@ -45,48 +102,58 @@ const TelemostPost: React.FC<{post: any}> = ({post}) => {
                         </a>
                     </span>
 
+                    {expired && (
+                        <div style={{marginTop: '12px', fontStyle: 'italic'}}>
+                            {'This meeting has ended.'}
+                        </div>
+                    )}
+
                     {/* Join button */}
-                    <div>
-                        <div style={{overflow: 'auto hidden', paddingRight: '5px', width: '100%'}}>
-                            <a
-                                className="btn btn-primary"
-                                rel="noopener noreferrer"
-                                target="_blank"
-                                href={joinURL}
-                                style={{
-                                    fontFamily: '"Open Sans", sans-serif',
-                                    fontSize: '12px',
-                                    fontWeight: 'bold',
-                                    letterSpacing: '1px',
-                                    lineHeight: '19px',
-                                    marginTop: '12px',
-                                    marginRight: '12px',
-                                    borderRadius: '4px',
-                                    color: '#fff',
-                                    backgroundColor: '#e56a52',
-                                    padding: '6px 12px',
-                                    display: 'inline-flex',
-                                    alignItems: 'center',
-                                    textDecoration: 'none'
-                                }}
-                            >
-                                {/* Video Icon */}
-                                <i style={{paddingRight: '8px', display: 'flex'}}>
-                                    <svg
-                                        width="19px"
-                                        height="100%"
-                                        viewBox="0 0 19 10"
-                                        xmlns="http://www.w3.org/2000/svg"
-                                        fill="white"
-                                    >
-                                        <path d="M1,0 L10,0 C12.2,0 14,1.8 14,4 L14,9 C14,9.6 13.6,10 13,10 L4,10 C1.8,10 0,8.2 0,6 L0,1 C0,0.4 0.4,0 1,0 Z"></path>
-                                        <path d="M15.4,2.9 L17.4,1.2 C17.8,0.9 18.4,0.9 18.8,1.4 C18.9,1.5 19,1.8 19,2 V9 C19,9.6 18.6,10 18,10 C17.8,10 17.5,9.9 17.4,9.8 L15.4,8.1 C15.1,7.9 15,7.7 15,7.4 V3.6 C15,3.3 15.1,3.1 15.4,2.9 Z"></path>
-                                    </svg>
-                                </i>
-                                JOIN MEETING
-                            </a>
+                    {!expired && (
+                        <div>
+                            <div style={{overflow: 'auto hidden', paddingRight: '5px', width: '100%'}}>
+                                <a
+                                    className="btn btn-primary"
+                                    rel="noopener noreferrer"
+                                    target="_blank"
+                                    href={joinURL}
+                                    style={{
+                                        fontFamily: '"Open Sans", sans-serif',
+                                        fontSize: '12px',
+                                        fontWeight: 'bold',
+                                        letterSpacing: '1px',
+                                        lineHeight: '19px',
+                                        marginTop: '12px',
+                                        marginRight: '12px',
+                                        borderRadius: '4px',
+                                        color: '#fff',
+                                        backgroundColor: '#e56a52',
+                                        padding: '6px 12px',
+                                        display: 'inline-flex',
+                                        alignItems: 'center',
+                                        textDecoration: 'none'
+                                    }}
+                                >
+                                    {/* Video Icon */}
+                                    <i style={{paddingRight: '8px', display: 'flex'}}>
+                                        <svg
+                                            width="19px"
+                                            height="100%"
+                                            viewBox="0 0 19 10"
+                                            xmlns="http://www.w3.org/2000/svg"
+                                            fill="white"
+                                        >
+                                            <path d="M1,0 L10,0 C12.2,0 14,1.8 14,4 L14,9 C14,9.6 13.6,10 13,10 L4,10 C1.8,10 0,8.2 0,6 L0,1 C0,0.4 0.4,0 1,0 Z"></path>
+                                            <path d="M15.4,2.9 L17.4,1.2 C17.8,0.9 18.4,0.9 18.8,1.4 C18.9,1.5 19,1.8 19,2 V9 C19,9.6 18.6,10 18,10 C17.8,10 17.5,9.9 17.4,9.8 L15.4,8.1 C15.1,7.9 15,7.7 15,7.4 V3.6 C15,3.3 15.1,3.1 15.4,2.9 Z"></path>
+                                        </svg>
+                                    </i>
+                                    JOIN MEETING
+                                </a>
+                            </div>
                         </div>
-                    </div>
+                    )}
+
+                    {post.props?.call && !expired && <CallResponse post={post}/>}
                 </div>
             </div>
         </div>
@@ -179,6 +246,18 @@ export default class Plugin {
             'Start Telemost Meeting',
         );
         console.log('Telemost plugin registered channel header button');
+
+        // Register post menu action to start a meeting about a post
+        registry.registerPostDropdownMenuAction(
+            'Start Telemost meeting about this post',
+            (postId: string) => {
+                doPost('/api/v1/meetings/thread', {post_id: postId}).catch((err) => {
+                    console.error('Telemost: failed to start meeting about post', err);
+                });
+            },
+            (post) => !post.type || !post.type.startsWith('system_'),
+        );
+        console.log('Telemost plugin registered post menu action');
         
         // Register app bar component
         if (registry.registerAppBarComponent) {